@@ -1,13 +1,17 @@
 package claude
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 
 	"auto-pr/internal/container"
+	"auto-pr/internal/logging"
+	"auto-pr/internal/metrics"
+	"auto-pr/internal/redact"
 )
 
 var claudePath string
@@ -22,48 +26,194 @@ func Detect() error {
 	return nil
 }
 
-// Run executes "claude -p <prompt>" in the given directory.
-// Output is written to both stdout and the provided writer (if non-nil).
-func Run(ctx context.Context, dir, prompt string, logWriter io.Writer) error {
-	args := []string{"-p", prompt, "--verbose"}
-	cmd := exec.CommandContext(ctx, claudePath, args...)
-	cmd.Dir = dir
+// Available reports whether Detect found a claude CLI on the host.
+func Available() bool {
+	return claudePath != ""
+}
 
-	if logWriter != nil {
-		cmd.Stdout = io.MultiWriter(os.Stdout, logWriter)
-		cmd.Stderr = io.MultiWriter(os.Stderr, logWriter)
+// Result is the summary claude prints at the end of a run (the "result"
+// message of --output-format json or stream-json). Fields are zero when the
+// summary could not be parsed (e.g. older claude versions).
+type Result struct {
+	Text         string
+	InputTokens  int // includes cache creation and cache read tokens
+	OutputTokens int
+	CostUSD      float64
+}
+
+// Options controls how claude is invoked.
+type Options struct {
+	// Verbose passes --verbose with --output-format stream-json, which
+	// streams every message (tool calls included) as it happens instead of
+	// only the final result.
+	Verbose bool
+	// AllowedTools is passed as --allowedTools ("" = claude's default).
+	AllowedTools string
+	// PermissionMode is passed as --permission-mode ("" = claude's default).
+	PermissionMode string
+	// ExtraArgs are passed verbatim before all other arguments.
+	ExtraArgs []string
+	// AppendSystemPrompt is passed as --append-system-prompt ("" = none).
+	AppendSystemPrompt string
+	// MCPConfig is the host path of an MCP config passed as --mcp-config.
+	// Container runs use the copy the container manager mounts instead.
+	MCPConfig string
+}
+
+// args builds the claude command-line arguments for a run.
+func (o Options) args(prompt string, cont bool) []string {
+	// Extra args go first so a flag taking a value cannot swallow -p.
+	args := append([]string{}, o.ExtraArgs...)
+	args = append(args, "-p", prompt)
+	if cont {
+		args = append(args, "--continue")
+	}
+	if o.Verbose {
+		args = append(args, "--output-format", "stream-json", "--verbose")
 	} else {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		args = append(args, "--output-format", "json")
+	}
+	if o.AllowedTools != "" {
+		args = append(args, "--allowedTools", o.AllowedTools)
+	}
+	if o.PermissionMode != "" {
+		args = append(args, "--permission-mode", o.PermissionMode)
+	}
+	if o.AppendSystemPrompt != "" {
+		args = append(args, "--append-system-prompt", o.AppendSystemPrompt)
 	}
+	if o.MCPConfig != "" {
+		args = append(args, "--mcp-config", o.MCPConfig)
+	}
+	return args
+}
 
-	return cmd.Run()
+// inContainer adapts o to a run in one of mgr's containers.
+func (o Options) inContainer(mgr *container.Manager) Options {
+	o.MCPConfig = ""
+	if mgr.MCPConfig != "" {
+		o.MCPConfig = container.MCPConfigPath
+	}
+	return o
+}
+
+// Run executes "claude -p <prompt>" in the given directory.
+// Output is written to both stdout and the provided writer (if non-nil).
+func Run(ctx context.Context, dir, prompt string, opts Options, logWriter io.Writer) (Result, error) {
+	metrics.ClaudeRuns.Inc()
+	return runLocal(ctx, dir, opts.args(prompt, false), logWriter)
 }
 
 // RunContinue executes "claude -p <prompt> --continue" in the given directory.
 // This continues the most recent conversation in that directory.
-func RunContinue(ctx context.Context, dir, prompt string, logWriter io.Writer) error {
-	args := []string{"-p", prompt, "--continue", "--verbose"}
+func RunContinue(ctx context.Context, dir, prompt string, opts Options, logWriter io.Writer) (Result, error) {
+	metrics.ClaudeRuns.Inc()
+	return runLocal(ctx, dir, opts.args(prompt, true), logWriter)
+}
+
+// RunInContainer executes "claude -p <prompt>" inside a Docker container.
+func RunInContainer(ctx context.Context, mgr *container.Manager, containerID, workDir, prompt string, opts Options, logWriter io.Writer) (Result, error) {
+	metrics.ClaudeRuns.Inc()
+	var out bytes.Buffer
+	err := mgr.Exec(ctx, containerID, workDir, append([]string{"claude"}, opts.inContainer(mgr).args(prompt, false)...), teeWriter(&out, logWriter))
+	return parseResult(out.Bytes()), err
+}
+
+// RunContinueInContainer executes "claude -p <prompt> --continue" inside a Docker container.
+func RunContinueInContainer(ctx context.Context, mgr *container.Manager, containerID, workDir, prompt string, opts Options, logWriter io.Writer) (Result, error) {
+	metrics.ClaudeRuns.Inc()
+	var out bytes.Buffer
+	err := mgr.Exec(ctx, containerID, workDir, append([]string{"claude"}, opts.inContainer(mgr).args(prompt, true)...), teeWriter(&out, logWriter))
+	return parseResult(out.Bytes()), err
+}
+
+func runLocal(ctx context.Context, dir string, args []string, logWriter io.Writer) (Result, error) {
 	cmd := exec.CommandContext(ctx, claudePath, args...)
 	cmd.Dir = dir
 
+	// What is shown and logged is redacted: Claude runs with GH_TOKEN and
+	// ANTHROPIC_API_KEY in its environment.
+	var out bytes.Buffer
+	var stdout, stderr *redact.Writer
 	if logWriter != nil {
-		cmd.Stdout = io.MultiWriter(os.Stdout, logWriter)
-		cmd.Stderr = io.MultiWriter(os.Stderr, logWriter)
+		stdout = redact.NewWriter(io.MultiWriter(logging.Stdout(), logWriter))
+		stderr = redact.NewWriter(io.MultiWriter(logging.Stderr(), logWriter))
 	} else {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		stdout = redact.NewWriter(logging.Stdout())
+		stderr = redact.NewWriter(logging.Stderr())
 	}
+	cmd.Stdout = io.MultiWriter(stdout, &out)
+	cmd.Stderr = stderr
 
-	return cmd.Run()
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	return parseResult(out.Bytes()), err
 }
 
-// RunInContainer executes "claude -p <prompt>" inside a Docker container.
-func RunInContainer(ctx context.Context, mgr *container.Manager, containerID, workDir, prompt string, logWriter io.Writer) error {
-	return mgr.Exec(ctx, containerID, workDir, []string{"claude", "-p", prompt, "--verbose"}, logWriter)
+func teeWriter(buf *bytes.Buffer, logWriter io.Writer) io.Writer {
+	if logWriter == nil {
+		return buf
+	}
+	return io.MultiWriter(logWriter, buf)
 }
 
-// RunContinueInContainer executes "claude -p <prompt> --continue" inside a Docker container.
-func RunContinueInContainer(ctx context.Context, mgr *container.Manager, containerID, workDir, prompt string, logWriter io.Writer) error {
-	return mgr.Exec(ctx, containerID, workDir, []string{"claude", "-p", prompt, "--continue", "--verbose"}, logWriter)
+// resultMessage is the final "result" message of claude's JSON output.
+type resultMessage struct {
+	Type         string  `json:"type"`
+	Result       string  `json:"result"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	CostUSD      float64 `json:"cost_usd"` // older claude versions
+	Usage        struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// parseResult extracts the run summary from claude's output: the last
+// "result" message of a stream-json run (one message per line), or the single
+// result object of a json run. A JSON array of messages, as older versions
+// print with --verbose, is accepted too. Parsing is best-effort: anything
+// unrecognised yields a zero Result. Lines are tried from the end because
+// container output may have stderr interleaved.
+func parseResult(out []byte) Result {
+	lines := bytes.Split(bytes.TrimSpace(out), []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := bytes.TrimSpace(lines[i])
+		if len(line) == 0 {
+			continue
+		}
+		var msgs []resultMessage
+		switch line[0] {
+		case '[':
+			if json.Unmarshal(line, &msgs) != nil {
+				continue
+			}
+		case '{':
+			var m resultMessage
+			if json.Unmarshal(line, &m) != nil {
+				continue
+			}
+			msgs = []resultMessage{m}
+		default:
+			continue
+		}
+		for j := len(msgs) - 1; j >= 0; j-- {
+			if m := msgs[j]; m.Type == "result" {
+				cost := m.TotalCostUSD
+				if cost == 0 {
+					cost = m.CostUSD
+				}
+				return Result{
+					Text:         m.Result,
+					InputTokens:  m.Usage.InputTokens + m.Usage.CacheCreationInputTokens + m.Usage.CacheReadInputTokens,
+					OutputTokens: m.Usage.OutputTokens,
+					CostUSD:      cost,
+				}
+			}
+		}
+	}
+	return Result{}
 }
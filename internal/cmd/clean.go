@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto-pr/internal/config"
+	"auto-pr/internal/ghcli"
+	"auto-pr/internal/state"
+	"auto-pr/internal/watch"
+	"auto-pr/internal/worktree"
+)
+
+// RunClean implements the "clean" subcommand.
+func RunClean(args []string) int {
+	fs := flag.NewFlagSet("clean", flag.ContinueOnError)
+	olderThan := fs.String("older-than", "", "Also remove done/failed issue state not updated for this long (e.g. 72h, 30d)")
+	all := fs.Bool("all", false, "Remove every worktree and all state auto-pr created")
+	force := fs.Bool("force", false, "Also remove state of in_progress/watching issues")
+	dryRun := fs.Bool("dry-run", false, "Show what would be removed")
+	help := fs.Bool("help", false, "Show help")
+	h := fs.Bool("h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *help || *h {
+		printCleanUsage()
+		return 0
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: Unknown argument '%s'\n", fs.Arg(0))
+		return 1
+	}
+	var age time.Duration
+	if *olderThan != "" {
+		if *all {
+			fmt.Fprintln(os.Stderr, "Error: --older-than and --all are mutually exclusive")
+			return 1
+		}
+		d, err := parseAge(*olderThan)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		age = d
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	cfg := config.Load(projectRoot)
+	stateDir := state.New(projectRoot)
+
+	active := activeIssues(stateDir)
+	if *all && len(active) > 0 && !*force {
+		fmt.Fprintf(os.Stderr, "Error: workers are active on issue(s) %s; stop watch first or use --force\n", joinNums(active))
+		return 1
+	}
+
+	ctx := context.Background()
+	if err := detectGitHub(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	repo, err := ghcli.RepoSlug(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+
+	c := &cleaner{projectRoot: projectRoot, dryRun: *dryRun}
+	for _, wt := range watch.ListWorktrees(ctx, repo, projectRoot, cfg.WorktreeDir, stateDir) {
+		switch {
+		case *all && wt.Kind != "":
+			c.removeWorktree(wt, "")
+		case wt.Orphaned:
+			c.removeWorktree(wt, wt.Remote)
+		}
+	}
+
+	switch {
+	case *all:
+		c.remove(c.rel(stateDir.Root), func() error { return os.RemoveAll(stateDir.Root) })
+	case age > 0:
+		c.pruneState(stateDir, time.Now().Add(-age), *force)
+	}
+
+	switch {
+	case c.removed == 0 && c.failed == 0:
+		fmt.Println("Nothing to clean.")
+	case *dryRun:
+		fmt.Printf("%d item(s) would be removed.\n", c.removed)
+	}
+	if c.failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cleaner removes things, or with dryRun only reports them, counting both.
+type cleaner struct {
+	projectRoot     string
+	dryRun          bool
+	removed, failed int
+}
+
+// rel shortens paths inside the project for display.
+func (c *cleaner) rel(path string) string {
+	if rel, err := filepath.Rel(c.projectRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func (c *cleaner) remove(what string, fn func() error) {
+	if c.dryRun {
+		fmt.Printf("Would remove %s\n", what)
+		c.removed++
+		return
+	}
+	if err := fn(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		c.failed++
+		return
+	}
+	fmt.Printf("Removed %s\n", what)
+	c.removed++
+}
+
+func (c *cleaner) removeWorktree(wt watch.Worktree, remote string) {
+	what := fmt.Sprintf("%s (%s)", c.rel(wt.Path), worktreeOwner(wt))
+	if remote != "" {
+		what = fmt.Sprintf("%s (%s, %s)", c.rel(wt.Path), worktreeOwner(wt), remote)
+	}
+	c.remove(what, func() error { return worktree.Remove(c.projectRoot, wt.Path) })
+}
+
+// pruneState removes the state and logs of done and failed issues (and of
+// active ones with force) last updated before cutoff, with their PR state.
+func (c *cleaner) pruneState(stateDir *state.Dir, cutoff time.Time, force bool) {
+	nums, err := stateDir.ListIssues()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		c.failed++
+		return
+	}
+	archived, _ := stateDir.ListArchivedIssues()
+	nums = append(nums, archived...)
+	for _, num := range nums {
+		s := stateDir.ReadIssue(num)
+		if s == nil {
+			continue
+		}
+		if mod, err := stateDir.IssueModTime(num); err != nil || mod.After(cutoff) {
+			continue
+		}
+		switch s.Status {
+		case state.IssueDone, state.IssueFailed:
+		case state.IssueInProgress, state.IssueWatching:
+			if !force {
+				fmt.Printf("Keeping issue #%d state (%s); use --force to remove it\n", num, s.Status)
+				continue
+			}
+		default:
+			continue
+		}
+		c.remove(fmt.Sprintf("issue #%d state and logs (%s)", num, s.Status), func() error {
+			if s.PRNumber > 0 {
+				if err := stateDir.RemovePR(s.PRNumber); err != nil {
+					return err
+				}
+			}
+			return stateDir.RemoveIssue(num)
+		})
+	}
+}
+
+// activeIssues returns the issues whose state says a worker is on them.
+func activeIssues(stateDir *state.Dir) []int {
+	nums, _ := stateDir.ListIssues()
+	var active []int
+	for _, num := range nums {
+		if s := stateDir.ReadIssue(num); s != nil && (s.Status == state.IssueInProgress || s.Status == state.IssueWatching) {
+			active = append(active, num)
+		}
+	}
+	return active
+}
+
+func joinNums(nums []int) string {
+	parts := make([]string, len(nums))
+	for i, n := range nums {
+		parts[i] = "#" + strconv.Itoa(n)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseAge parses a Go duration, or a number of days such as "30d".
+func parseAge(val string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(val, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(val); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid --older-than %q: expected a duration like 72h or 30d", val)
+}
+
+func printCleanUsage() {
+	fmt.Println("Usage: auto-pr clean [--older-than DURATION] [--all] [--force] [--dry-run]")
+	fmt.Println()
+	fmt.Println("Removes worktrees of closed issues and closed or merged PRs, like")
+	fmt.Println("'auto-pr worktree prune'.")
+	fmt.Println()
+	fmt.Println("  --older-than DURATION  Also remove the state, PR state and logs of done and")
+	fmt.Println("                         failed issues not updated for DURATION (72h, 30d)")
+	fmt.Println("  --all                  Remove every worktree auto-pr created and the whole")
+	fmt.Println("                         .pr-watch-state directory")
+	fmt.Println("  --force                Also remove in_progress/watching issues (refused otherwise)")
+	fmt.Println("  --dry-run              Show what would be removed")
+	fmt.Println()
+	fmt.Println("An open issue whose state is removed counts as new to 'watch --repo' and")
+	fmt.Println("is picked up again if it still matches ISSUE_LABELS. Stop watch before")
+	fmt.Println("cleaning.")
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"auto-pr/internal/config"
+	"auto-pr/internal/container"
+	"auto-pr/internal/ignore"
+)
+
+// RunConfig implements the "config" subcommand.
+func RunConfig(args []string) int {
+	if len(args) == 0 {
+		printConfigUsage()
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		printConfigUsage()
+		return 0
+	}
+	action := args[0]
+	if action != "check" && action != "show" {
+		fmt.Fprintf(os.Stderr, "Error: Unknown config action '%s'\n\n", action)
+		printConfigUsage()
+		return 1
+	}
+
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	var cf *configFlags
+	if action == "show" {
+		cf = addConfigFlags(fs)
+	}
+	help := fs.Bool("help", false, "Show help")
+	h := fs.Bool("h", false, "Show help")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 1
+	}
+	if *help || *h {
+		printConfigUsage()
+		return 0
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: Unknown argument '%s'\n", fs.Arg(0))
+		return 1
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	if action == "show" {
+		return showConfig(projectRoot, cf)
+	}
+
+	cfg, warnings := config.LoadWithWarnings(projectRoot)
+	problems := cfg.Check()
+
+	name := config.Path(projectRoot)
+	if rel, err := filepath.Rel(projectRoot, name); err == nil && !strings.HasPrefix(rel, "..") {
+		name = rel
+	}
+	if len(warnings) == 0 && len(problems) == 0 {
+		fmt.Printf("%s: no problems found.\n", name)
+	}
+	for _, w := range warnings {
+		fmt.Printf("%s:%d: %s\n", name, w.Line, w.Msg)
+	}
+	for _, p := range problems {
+		fmt.Printf("%s: %s\n", name, p)
+	}
+
+	fmt.Println()
+	fmt.Println("Effective configuration:")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, e := range cfg.Entries() {
+		fmt.Fprintf(tw, "  %s\t%q\n", e.Key, e.Value)
+	}
+	tw.Flush()
+
+	if len(warnings) > 0 || len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// showConfig prints the configuration watch would run with given the same
+// flags, with the source of each value.
+func showConfig(projectRoot string, cf *configFlags) int {
+	cfg, sources, _ := config.LoadWithSources(projectRoot)
+	if err := cf.apply(&cfg, sources); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, e := range cfg.Entries() {
+		fmt.Fprintf(tw, "%s\t%q\t%s\n", e.Key, e.Value, sources.Of(e.Key))
+	}
+	tw.Flush()
+
+	fmt.Println()
+	fmt.Println("Resolved for watch:")
+	switch cfg.BotLogin {
+	case "":
+		fmt.Println("  bot login:      the gh-authenticated user")
+	case "none":
+		fmt.Println("  bot login:      none (no comments are filtered)")
+	default:
+		fmt.Printf("  bot login:      %s\n", cfg.BotLogin)
+	}
+	if cfg.DockerEnabled {
+		user, err := container.ResolveUser(cfg.DockerUser)
+		switch {
+		case err != nil:
+			fmt.Printf("  container user: invalid (%v)\n", err)
+		case user == "":
+			fmt.Println("  container user: root")
+		default:
+			fmt.Printf("  container user: %s\n", user)
+		}
+	}
+	ign, err := ignore.Load(projectRoot)
+	switch {
+	case err != nil:
+		fmt.Printf("  %s:  unreadable (%v)\n", ignore.FileName, err)
+	case ign == nil:
+		fmt.Printf("  %s:  none\n", ignore.FileName)
+	default:
+		fmt.Printf("  %s:  %d pattern(s)\n", ignore.FileName, len(ign.Patterns()))
+	}
+	return 0
+}
+
+func printConfigUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  auto-pr config check                Report problems in .pr-watch.conf and print the effective config")
+	fmt.Println("  auto-pr config show [watch flags]   Print the config watch would use, with each value's source")
+	fmt.Println("  auto-pr config --help               Show this help")
+	fmt.Println()
+	fmt.Println("check reports unknown keys, malformed lines, invalid values (which fall")
+	fmt.Println("back to the default) and settings that contradict each other, with line")
+	fmt.Println("numbers. It exits 1 when anything was reported.")
+	fmt.Println()
+	fmt.Println("show accepts the watch flags that change the config (--interval,")
+	fmt.Println("--max-concurrent, --max-issues, --docker, --verbose, --quiet, --set KEY=VALUE)")
+	fmt.Println("and labels each value default, .pr-watch.conf, --set or flag.")
+}
+
+// configFlags are the watch flags that override .pr-watch.conf, shared by
+// watch and config show.
+type configFlags struct {
+	fs            *flag.FlagSet
+	interval      *int
+	maxConcurrent *int
+	maxIssues     *int
+	docker        *bool
+	verbose       *bool
+	quiet         *bool
+	overrides     stringList
+}
+
+func addConfigFlags(fs *flag.FlagSet) *configFlags {
+	f := &configFlags{fs: fs}
+	f.interval = fs.Int("interval", 0, "Poll interval in seconds")
+	f.maxConcurrent = fs.Int("max-concurrent", 0, "Max concurrent worker processes")
+	f.maxIssues = fs.Int("max-issues", 0, "New issues started per scan (0 = no limit)")
+	f.docker = fs.Bool("docker", false, "Run workers in Docker containers for isolation")
+	f.verbose = fs.Bool("verbose", false, "Also print debug output")
+	f.quiet = fs.Bool("quiet", false, "Only print warnings and errors")
+	fs.Var(&f.overrides, "set", "Override a config key for this run (KEY=VALUE, repeatable)")
+	return f
+}
+
+// apply merges the parsed flags into cfg: --set overrides first, then the
+// dedicated flags. The keys they change are recorded in sources (if not
+// nil). Flags that were given but cannot take effect are errors.
+func (f *configFlags) apply(cfg *config.Config, sources config.Sources) error {
+	set := func(key string, src config.Source) {
+		if sources != nil {
+			sources[key] = src
+		}
+	}
+	for _, kv := range f.overrides {
+		if err := cfg.ApplyOverride(kv); err != nil {
+			return fmt.Errorf("--set: %w", err)
+		}
+		key, _, _ := strings.Cut(kv, "=")
+		set(strings.TrimSpace(key), config.SourceSet)
+	}
+
+	given := map[string]bool{}
+	f.fs.Visit(func(fl *flag.Flag) { given[fl.Name] = true })
+	if given["interval"] {
+		if *f.interval <= 0 {
+			return fmt.Errorf("--interval must be a positive number of seconds, got %d", *f.interval)
+		}
+		cfg.Interval = *f.interval
+		set("INTERVAL", config.SourceFlag)
+	}
+	if given["max-concurrent"] {
+		if *f.maxConcurrent <= 0 {
+			return fmt.Errorf("--max-concurrent must be positive, got %d", *f.maxConcurrent)
+		}
+		cfg.MaxConcurrent = *f.maxConcurrent
+		set("MAX_CONCURRENT", config.SourceFlag)
+	}
+	if given["max-issues"] {
+		if *f.maxIssues < 0 {
+			return fmt.Errorf("--max-issues must not be negative, got %d", *f.maxIssues)
+		}
+		cfg.MaxIssuesPerScan = *f.maxIssues
+		set("MAX_ISSUES_PER_SCAN", config.SourceFlag)
+	}
+	if *f.docker {
+		cfg.DockerEnabled = true
+		set("DOCKER", config.SourceFlag)
+	}
+	switch {
+	case *f.verbose && *f.quiet:
+		return fmt.Errorf("--verbose and --quiet are mutually exclusive")
+	case *f.verbose:
+		cfg.LogLevel = "debug"
+		set("LOG_LEVEL", config.SourceFlag)
+	case *f.quiet:
+		cfg.LogLevel = "warn"
+		set("LOG_LEVEL", config.SourceFlag)
+	}
+	return nil
+}
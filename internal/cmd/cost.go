@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"auto-pr/internal/state"
+)
+
+// issueCost is one row of the cost report.
+type issueCost struct {
+	Issue        int               `json:"issue"`
+	Status       state.IssueStatus `json:"status"`
+	PRNumber     int               `json:"pr_number,omitempty"`
+	Labels       []string          `json:"labels,omitempty"`
+	InputTokens  int               `json:"input_tokens"`
+	OutputTokens int               `json:"output_tokens"`
+	CostUSD      float64           `json:"cost_usd"`
+}
+
+// RunCost implements the "cost" subcommand.
+func RunCost(args []string) int {
+	fs := flag.NewFlagSet("cost", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "Raw JSON output")
+	groupBy := fs.String("group-by", "", "Aggregate by 'label' or 'area' (labels like area/x)")
+	help := fs.Bool("help", false, "Show help")
+	h := fs.Bool("h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if *help || *h {
+		fmt.Println("Usage: auto-pr cost [--group-by label|area] [--json]")
+		fmt.Println()
+		fmt.Println("  Show Claude token usage and cost per issue, from .pr-watch-state.")
+		fmt.Println("  --group-by label   Aggregate spend per issue label")
+		fmt.Println("  --group-by area    Aggregate spend per area label (area/x or area:x)")
+		fmt.Println("  --json             Raw JSON output")
+		fmt.Println()
+		fmt.Println("  An issue with several labels counts toward each of them, so grouped")
+		fmt.Println("  totals can exceed the overall total.")
+		return 0
+	}
+	if *groupBy != "" && *groupBy != "label" && *groupBy != "area" {
+		fmt.Fprintf(os.Stderr, "Error: --group-by must be 'label' or 'area', got '%s'\n", *groupBy)
+		return 1
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	stateDir := state.New(projectRoot)
+
+	nums, err := stateDir.ListIssues()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	archived, _ := stateDir.ListArchivedIssues()
+	nums = append(nums, archived...)
+	sort.Ints(nums)
+
+	var rows []issueCost
+	var total issueCost
+	for _, num := range nums {
+		s := stateDir.ReadIssue(num)
+		if s == nil || s.Status == state.IssuePreexisting {
+			continue
+		}
+		row := issueCost{
+			Issue:        num,
+			Status:       s.Status,
+			PRNumber:     s.PRNumber,
+			Labels:       s.Labels,
+			InputTokens:  s.TotalInputTokens,
+			OutputTokens: s.TotalOutputTokens,
+			CostUSD:      s.TotalCostUSD,
+		}
+		rows = append(rows, row)
+		total.InputTokens += row.InputTokens
+		total.OutputTokens += row.OutputTokens
+		total.CostUSD += row.CostUSD
+	}
+
+	if *groupBy != "" {
+		return printCostGroups(groupCosts(rows, *groupBy), *groupBy, *jsonOut)
+	}
+
+	if *jsonOut {
+		out := struct {
+			Issues       []issueCost `json:"issues"`
+			InputTokens  int         `json:"total_input_tokens"`
+			OutputTokens int         `json:"total_output_tokens"`
+			CostUSD      float64     `json:"total_cost_usd"`
+		}{rows, total.InputTokens, total.OutputTokens, total.CostUSD}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+		return 0
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No issue usage recorded yet.")
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ISSUE\tSTATUS\tPR\tINPUT\tOUTPUT\tCOST")
+	for _, r := range rows {
+		pr := "-"
+		if r.PRNumber > 0 {
+			pr = fmt.Sprintf("#%d", r.PRNumber)
+		}
+		fmt.Fprintf(tw, "#%d\t%s\t%s\t%d\t%d\t$%.4f\n", r.Issue, r.Status, pr, r.InputTokens, r.OutputTokens, r.CostUSD)
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t%d\t%d\t$%.4f\n", total.InputTokens, total.OutputTokens, total.CostUSD)
+	tw.Flush()
+	return 0
+}
+
+// costGroup aggregates usage for one label or area.
+type costGroup struct {
+	Key          string  `json:"key"`
+	Issues       int     `json:"issues"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// groupCosts aggregates rows per label, or per area label when by is "area".
+// Issues without a matching label are grouped under "(none)".
+func groupCosts(rows []issueCost, by string) []costGroup {
+	groups := map[string]*costGroup{}
+	for _, r := range rows {
+		var keys []string
+		for _, l := range r.Labels {
+			if by == "label" {
+				keys = append(keys, l)
+			} else if area, ok := areaOf(l); ok {
+				keys = append(keys, area)
+			}
+		}
+		if len(keys) == 0 {
+			keys = []string{"(none)"}
+		}
+		for _, k := range keys {
+			g, ok := groups[k]
+			if !ok {
+				g = &costGroup{Key: k}
+				groups[k] = g
+			}
+			g.Issues++
+			g.InputTokens += r.InputTokens
+			g.OutputTokens += r.OutputTokens
+			g.CostUSD += r.CostUSD
+		}
+	}
+
+	out := make([]costGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CostUSD != out[j].CostUSD {
+			return out[i].CostUSD > out[j].CostUSD
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// areaOf extracts the area from labels like "area/api" or "area:api".
+func areaOf(label string) (string, bool) {
+	for _, prefix := range []string{"area/", "area:"} {
+		if strings.HasPrefix(strings.ToLower(label), prefix) {
+			return label[len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+func printCostGroups(groups []costGroup, by string, jsonOut bool) int {
+	if jsonOut {
+		out := struct {
+			GroupBy string      `json:"group_by"`
+			Groups  []costGroup `json:"groups"`
+		}{by, groups}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+		return 0
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("No issue usage recorded yet.")
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tISSUES\tINPUT\tOUTPUT\tCOST\n", strings.ToUpper(by))
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t$%.4f\n", g.Key, g.Issues, g.InputTokens, g.OutputTokens, g.CostUSD)
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"auto-pr/internal/claude"
+	"auto-pr/internal/config"
+	"auto-pr/internal/container"
+	"auto-pr/internal/ghcli"
+	"auto-pr/internal/github"
+)
+
+// RunDoctor implements the "doctor" subcommand.
+func RunDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	dockerFlag := fs.Bool("docker", false, "Check Docker mode even if DOCKER is off")
+	help := fs.Bool("help", false, "Show help")
+	h := fs.Bool("h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if *help || *h {
+		fmt.Println("Usage: auto-pr doctor [--docker]")
+		fmt.Println()
+		fmt.Println("  Check that auto-pr watch can run here: gh and its login, the repo,")
+		fmt.Println("  the claude CLI, Docker, and how Claude will authenticate in the")
+		fmt.Println("  selected mode (local or Docker).")
+		fmt.Println("  --docker   Check Docker mode even if DOCKER is off in .pr-watch.conf")
+		return 0
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	cfg := config.Load(projectRoot)
+	dockerEnabled := cfg.DockerEnabled || *dockerFlag
+	ctx := context.Background()
+
+	failed := false
+	check := func(name string, detail string, err error) {
+		if err != nil {
+			fmt.Printf("[FAIL] %-8s %v\n", name, err)
+			failed = true
+			return
+		}
+		fmt.Printf("[ ok ] %-8s %s\n", name, detail)
+	}
+
+	// GitHub
+	if err := detectGitHub(cfg); err != nil {
+		check("gh", "", err)
+	} else {
+		check("gh", fmt.Sprintf("%s (%s)", ghcli.Version(), ghcli.Path()), nil)
+		login, err := github.CurrentUser(ctx)
+		check("gh auth", "logged in as "+login, err)
+		repo, err := ghcli.RepoSlug(ctx)
+		check("repo", repo, err)
+	}
+
+	// Claude
+	if dockerEnabled {
+		if err := container.Detect(); err != nil {
+			check("docker", "", err)
+		} else {
+			user, err := container.ResolveUser(cfg.DockerUser)
+			if user == "" {
+				user = "root"
+			}
+			check("docker", "available (image: "+cfg.DockerImage+", user: "+user+")", err)
+		}
+		how, err := container.ClaudeAuth()
+		check("claude", "containers authenticate with "+how, err)
+		if cfg.DockerFallbackLocal {
+			if err := claude.Detect(); err != nil {
+				fmt.Printf("[warn] %-8s DOCKER_FALLBACK_LOCAL is set but %v\n", "claude", err)
+			}
+		}
+	} else {
+		if err := claude.Detect(); err != nil {
+			check("claude", "", err)
+		} else {
+			check("claude", "CLI found; runs on the host with its own login", nil)
+		}
+	}
+
+	if failed {
+		fmt.Println()
+		fmt.Println("Some checks failed; auto-pr watch will not work until they are fixed.")
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"auto-pr/internal/config"
+	"auto-pr/internal/container"
+)
+
+// RunImage implements the "image" subcommand.
+func RunImage(args []string) int {
+	if len(args) == 0 {
+		printImageUsage()
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		printImageUsage()
+		return 0
+	}
+	action := args[0]
+	if action != "build" && action != "pull" && action != "rm" {
+		fmt.Fprintf(os.Stderr, "Error: Unknown image action '%s'\n\n", action)
+		printImageUsage()
+		return 1
+	}
+
+	fs := flag.NewFlagSet("image", flag.ContinueOnError)
+	noCache := fs.Bool("no-cache", false, "Build without Docker's layer cache (build)")
+	help := fs.Bool("help", false, "Show help")
+	h := fs.Bool("h", false, "Show help")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 1
+	}
+	if *help || *h {
+		printImageUsage()
+		return 0
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: Unknown argument '%s'\n", fs.Arg(0))
+		return 1
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	cfg := config.Load(projectRoot)
+	if err := container.Detect(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	mgr := container.NewManager(cfg.DockerImage, projectRoot, cfg.DockerFile)
+	ctx := context.Background()
+
+	switch action {
+	case "build":
+		if err := mgr.Build(ctx, *noCache); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		return 0
+	case "pull":
+		if err := mgr.PullImage(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		return 0
+	}
+
+	// rm
+	existed, err := mgr.RemoveImage(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	if existed {
+		fmt.Printf("Removed image %s.\n", cfg.DockerImage)
+	} else {
+		fmt.Printf("No image %s.\n", cfg.DockerImage)
+	}
+	return 0
+}
+
+func printImageUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  auto-pr image build [--no-cache]  Build (or rebuild) the worker image now")
+	fmt.Println("  auto-pr image pull                Pull the worker image from its registry")
+	fmt.Println("  auto-pr image rm                  Delete the worker image")
+	fmt.Println("  auto-pr image --help              Show this help")
+	fmt.Println()
+	fmt.Println("The image is DOCKER_IMAGE from .pr-watch.conf, built from DOCKER_FILE,")
+	fmt.Println("else Dockerfile.autopr in the project root, else the embedded default.")
+	fmt.Println("watch builds it on first use if it is missing (or pulls it with")
+	fmt.Println("DOCKER_IMAGE_PULL=true); doing that ahead of time avoids the wait.")
+}
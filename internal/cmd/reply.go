@@ -3,11 +3,16 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"auto-pr/internal/ghcli"
 	"auto-pr/internal/github"
+	"auto-pr/internal/state"
+	"auto-pr/internal/worktree"
 )
 
 // RunReply implements the "reply" subcommand.
@@ -24,7 +29,7 @@ func RunReply(args []string) int {
 
 	ctx := context.Background()
 
-	if err := ghcli.Detect(); err != nil {
+	if err := detectGitHub(projectConfig()); err != nil {
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		return 1
 	}
@@ -38,10 +43,15 @@ func RunReply(args []string) int {
 	// --list mode
 	if args[0] == "--list" {
 		prNum := 0
-		if len(args) > 1 {
-			n, err := strconv.Atoi(args[1])
+		unresolved := false
+		for _, arg := range args[1:] {
+			if arg == "--unresolved" {
+				unresolved = true
+				continue
+			}
+			n, err := strconv.Atoi(arg)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Invalid PR number '%s'\n", args[1])
+				fmt.Fprintf(os.Stderr, "Error: Invalid PR number '%s'\n", arg)
 				return 1
 			}
 			prNum = n
@@ -53,7 +63,7 @@ func RunReply(args []string) int {
 				fmt.Fprintln(os.Stderr, "Error:", err)
 				return 1
 			}
-			prNum, err = github.FindPRForBranch(ctx, repo, branch)
+			prNum, err = github.FindPRForBranch(ctx, repo, branch, true)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				return 1
@@ -66,16 +76,51 @@ func RunReply(args []string) int {
 			return 1
 		}
 
-		fmt.Printf("Comments on PR #%d that can be replied to:\n\n", prNum)
+		what := "Comments"
+		if unresolved {
+			open, err := github.UnresolvedCommentIDs(ctx, repo, prNum)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				return 1
+			}
+			kept := comments[:0]
+			for _, c := range comments {
+				if open[c.ID] {
+					kept = append(kept, c)
+				}
+			}
+			comments = kept
+			what = "Comments in unresolved threads"
+		}
+
+		fmt.Printf("%s on PR #%d that can be replied to:\n\n", what, prNum)
 		for _, c := range comments {
 			firstLine := firstLineOf(c.Body)
-			fmt.Printf("  ID: %d  @%s  %s:%s\n  %s\n\n",
-				c.ID, c.User.Login, c.Path, c.LineDisplay(), firstLine)
+			tag := ""
+			if github.HasSuggestion(c.Body) {
+				tag = "  [suggestion]"
+			}
+			fmt.Printf("  ID: %d  @%s  %s:%s%s\n  %s\n\n",
+				c.ID, c.User.Login, c.Path, c.LineDisplay(), tag, firstLine)
 		}
 		return 0
 	}
 
-	// Reply mode: pr-reply <comment_id> "body"
+	// Reply mode: pr-reply [--issue] <comment_id> "body" | - | --body-file <path>
+	conversation := false
+	if args[0] == "--issue" {
+		conversation = true
+		args = args[1:]
+	}
+	args, sugg, err := parseSuggestFlags(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	if sugg != nil && conversation {
+		fmt.Fprintln(os.Stderr, "Error: Suggestions can only be posted on inline review comments, not with --issue.")
+		return 1
+	}
 	if len(args) < 2 {
 		fmt.Fprintln(os.Stderr, "Error: Missing reply body.")
 		fmt.Fprintln(os.Stderr, "Usage: auto-pr reply <comment_id> \"reply body\"")
@@ -87,26 +132,235 @@ func RunReply(args []string) int {
 		fmt.Fprintf(os.Stderr, "Error: comment_id must be a number, got '%s'.\n", args[0])
 		return 1
 	}
-	replyBody := args[1]
+	replyBody, err := readReplyBody(args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	if sugg != nil {
+		return postSuggestion(ctx, repo, commentID, replyBody, sugg)
+	}
+	if strings.TrimSpace(replyBody) == "" {
+		fmt.Fprintln(os.Stderr, "Error: Reply body is empty.")
+		return 1
+	}
+
+	if conversation {
+		return replyInConversation(ctx, repo, commentID, replyBody, nil)
+	}
 
 	// Post reply
-	endpoint := fmt.Sprintf("repos/%s/pulls/comments/%d/replies", repo, commentID)
-	var resp github.ReplyResponse
-	err = ghcli.APITyped(ctx, endpoint, &resp, "-f", "body="+replyBody)
+	resp, err := github.ReplyToComment(ctx, repo, commentID, replyBody)
 	if err != nil {
+		// Not an inline comment? Answer a conversation comment or a
+		// top-level review in the PR conversation instead.
+		return replyInConversation(ctx, repo, commentID, replyBody, err)
+	}
+
+	fmt.Printf("Reply posted (ID: %d) by @%s\n", resp.ID, resp.User.Login)
+	recordReply(commentID, resp)
+	return 0
+}
+
+// replyInConversation answers an issue/PR conversation comment or, failing
+// that, a top-level review on the current branch's PR, with a new comment in
+// the conversation that quotes it. inlineErr is the error from trying id as
+// an inline comment, reported if id is neither.
+func replyInConversation(ctx context.Context, repo string, id int, body string, inlineErr error) int {
+	num, author, quoted := 0, "", ""
+	if c, err := github.GetIssueComment(ctx, repo, id); err == nil {
+		num, author, quoted = c.IssueNumber(), c.User.Login, c.Body
+	} else if inlineErr != nil {
+		if branch, err := github.CurrentBranch(); err == nil {
+			if prNum, err := github.FindPRForBranch(ctx, repo, branch, true); err == nil {
+				if r, err := github.GetReview(ctx, repo, prNum, id); err == nil {
+					num, author, quoted = prNum, r.User.Login, r.Body
+				}
+			}
+		}
+	}
+	if num == 0 {
 		fmt.Fprintln(os.Stderr, "Error: Failed to post reply. Check comment ID and permissions.")
+		if inlineErr != nil {
+			fmt.Fprintln(os.Stderr, inlineErr)
+		} else {
+			fmt.Fprintf(os.Stderr, "No issue or PR conversation comment with ID %d.\n", id)
+		}
+		return 1
+	}
+
+	text := fmt.Sprintf("@%s %s", author, body)
+	if q := strings.TrimSpace(firstLineOf(quoted)); q != "" {
+		text = "> " + q + "\n\n" + text
+	}
+	resp, err := github.PostIssueComment(ctx, repo, num, text)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: Failed to post reply. Check permissions.")
 		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
+	fmt.Printf("Reply posted on #%d (ID: %d) by @%s\n", num, resp.ID, resp.User.Login)
+	return 0
+}
 
-	fmt.Printf("Reply posted (ID: %d) by @%s\n", resp.ID, resp.User.Login)
+// suggestFlags are the reply options that turn the body into a ```suggestion.
+type suggestFlags struct {
+	file       string // --suggest-file: suggest on these lines instead of the thread's
+	start, end int    // --suggest-lines
+}
+
+// parseSuggestFlags removes --suggestion, --suggest-file and --suggest-lines
+// from args. The returned flags are nil when none was given.
+func parseSuggestFlags(args []string) ([]string, *suggestFlags, error) {
+	var rest []string
+	given := false
+	file, lines := "", ""
+	value := func(i *int, name string) (string, error) {
+		if v, ok := strings.CutPrefix(args[*i], name+"="); ok {
+			return v, nil
+		}
+		if *i+1 >= len(args) {
+			return "", fmt.Errorf("%s needs a value", name)
+		}
+		*i++
+		return args[*i], nil
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var err error
+		switch {
+		case arg == "--suggestion":
+		case arg == "--suggest-file" || strings.HasPrefix(arg, "--suggest-file="):
+			file, err = value(&i, "--suggest-file")
+		case arg == "--suggest-lines" || strings.HasPrefix(arg, "--suggest-lines="):
+			lines, err = value(&i, "--suggest-lines")
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		given = true
+	}
+	if !given {
+		return rest, nil, nil
+	}
+	s := &suggestFlags{file: file}
+	switch {
+	case file == "" && lines != "":
+		return nil, nil, fmt.Errorf("--suggest-lines needs --suggest-file")
+	case file != "" && lines == "":
+		return nil, nil, fmt.Errorf("--suggest-file needs --suggest-lines")
+	case lines != "":
+		a, b, isRange := strings.Cut(lines, "-")
+		if !isRange {
+			b = a
+		}
+		start, err1 := strconv.Atoi(a)
+		end, err2 := strconv.Atoi(b)
+		if err1 != nil || err2 != nil || start <= 0 || end < start {
+			return nil, nil, fmt.Errorf("--suggest-lines must be a line number or a range like 10-12, got '%s'", lines)
+		}
+		s.start, s.end = start, end
+	}
+	return rest, s, nil
+}
+
+// postSuggestion posts code as a ```suggestion: as a reply in the comment's
+// thread, replacing the lines it is on, or with --suggest-file as a new
+// comment on those lines of the comment's PR at its current head.
+func postSuggestion(ctx context.Context, repo string, commentID int, code string, s *suggestFlags) int {
+	body := github.SuggestionBody(code)
+	var resp *github.ReplyResponse
+	var err error
+	if s.file == "" {
+		resp, err = github.ReplyToComment(ctx, repo, commentID, body)
+	} else {
+		var c *github.ReviewComment
+		c, err = github.GetReviewComment(ctx, repo, commentID)
+		if err == nil {
+			prNum := c.PRNumber()
+			var head string
+			head, err = github.GetPRHeadSHA(ctx, repo, prNum)
+			if err == nil {
+				resp, err = github.CommentOnLines(ctx, repo, prNum, head, s.file, s.start, s.end, body)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: Failed to post suggestion. Check the comment ID, the file and lines (they must be part of the PR's diff) and permissions.")
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Suggestion posted (ID: %d) by @%s\n", resp.ID, resp.User.Login)
+	recordReply(commentID, resp)
 	return 0
 }
 
+// recordReply marks the replied-to comment and the reply itself as handled in
+// the watch state (if present), so the watcher never processes them again.
+// The state lives in the main repository, also when replying from one of its
+// worktrees.
+func recordReply(commentID int, resp *github.ReplyResponse) {
+	prNum := resp.PRNumber()
+	if prNum == 0 {
+		return
+	}
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return
+	}
+	stateDir := state.New(worktree.MainRoot(projectRoot))
+	if info, err := os.Stat(stateDir.Root); err != nil || !info.IsDir() {
+		return // not watched; don't create state as a side effect
+	}
+	os.MkdirAll(filepath.Join(stateDir.Root, "prs"), 0755)
+	stateDir.MarkCommentHandled(prNum, commentID, resp.ID)
+}
+
+// readReplyBody returns the reply body given after the comment ID: the
+// argument itself, stdin for "-", or a file for "--body-file <path>". A single
+// trailing newline (as left by editors and heredocs) is dropped.
+func readReplyBody(args []string) (string, error) {
+	var data []byte
+	var err error
+	switch {
+	case args[0] == "-":
+		data, err = io.ReadAll(os.Stdin)
+	case args[0] == "--body-file":
+		if len(args) < 2 {
+			return "", fmt.Errorf("--body-file needs a path")
+		}
+		data, err = os.ReadFile(args[1])
+	case strings.HasPrefix(args[0], "--body-file="):
+		data, err = os.ReadFile(strings.TrimPrefix(args[0], "--body-file="))
+	default:
+		return args[0], nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read reply body: %w", err)
+	}
+	body := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(body, "\r"), nil
+}
+
 func printReplyUsage() {
 	fmt.Println("Usage:")
 	fmt.Println("  auto-pr reply <comment_id> \"reply body\"   Reply to a review comment")
+	fmt.Println("  auto-pr reply <comment_id> -               ... reading the body from stdin")
+	fmt.Println("  auto-pr reply <comment_id> --body-file F   ... reading the body from file F")
+	fmt.Println("  auto-pr reply --issue <comment_id> \"body\"  Reply to an issue/PR conversation comment")
+	fmt.Println("  auto-pr reply <comment_id> --suggestion \"code\"")
+	fmt.Println("                                             Reply with a ```suggestion replacing the comment's lines")
+	fmt.Println("  auto-pr reply <comment_id> --suggest-file F --suggest-lines A-B \"code\"")
+	fmt.Println("                                             Suggest replacing lines A-B of F instead, as a new comment")
+	fmt.Println()
+	fmt.Println("An ID that is not an inline review comment is looked up as a conversation")
+	fmt.Println("comment, then as a top-level review on the current branch's PR; the reply is")
+	fmt.Println("posted in that conversation, quoting the comment and mentioning its author.")
 	fmt.Println("  auto-pr reply --list [PR_NUMBER]           List comment IDs available for reply")
+	fmt.Println("  auto-pr reply --list --unresolved [PR]     Only comments in unresolved threads")
 	fmt.Println("  auto-pr reply --help                       Show this help")
 }
 
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"auto-pr/internal/config"
+	"auto-pr/internal/ghcli"
+	"auto-pr/internal/github"
+	"auto-pr/internal/state"
+)
+
+// reportIssue is one issue of the activity report.
+type reportIssue struct {
+	Issue        int               `json:"issue"`
+	Status       state.IssueStatus `json:"status"`
+	FailedReason string            `json:"failed_reason,omitempty"`
+	Archived     bool              `json:"archived,omitempty"`
+	Labels       []string          `json:"labels,omitempty"`
+	PRNumber     int               `json:"pr_number,omitempty"`
+	PRState      string            `json:"pr_state,omitempty"` // live: open, closed or merged
+	ReviewRounds int               `json:"review_rounds,omitempty"`
+	InputTokens  int               `json:"input_tokens"`
+	OutputTokens int               `json:"output_tokens"`
+	CostUSD      float64           `json:"cost_usd"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// reportPR is a watched PR not owned by an issue worker (single-PR mode).
+type reportPR struct {
+	Number    int       `json:"number"`
+	Branch    string    `json:"branch,omitempty"`
+	State     string    `json:"state,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// reportTotals are the aggregate counts of the report.
+type reportTotals struct {
+	Issues       int     `json:"issues"`
+	Opened       int     `json:"opened"` // issues with a PR
+	Merged       int     `json:"merged"`
+	Failed       int     `json:"failed"`
+	InFlight     int     `json:"in_flight"` // in_progress or watching
+	NeedsHuman   int     `json:"needs_human"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+type report struct {
+	Repo        string        `json:"repo"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Since       *time.Time    `json:"since,omitempty"`
+	Totals      reportTotals  `json:"totals"`
+	Issues      []reportIssue `json:"issues"`
+	PRs         []reportPR    `json:"prs"`
+}
+
+// RunReport implements the "report" subcommand.
+func RunReport(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "Raw JSON output")
+	since := fs.String("since", "", "Only issues and PRs whose state changed since (RFC3339, or a duration like 168h)")
+	help := fs.Bool("help", false, "Show help")
+	h := fs.Bool("h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *help || *h {
+		printReportUsage()
+		return 0
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: Unknown argument '%s'\n", fs.Arg(0))
+		return 1
+	}
+
+	now := time.Now()
+	var cutoff time.Time
+	if *since != "" {
+		t, err := parseSince(*since, now)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		cutoff = t
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	cfg := config.Load(projectRoot)
+	ctx := context.Background()
+	if err := detectGitHub(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	repo, err := ghcli.RepoSlug(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+
+	r, err := buildReport(ctx, repo, state.New(projectRoot), cutoff)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	r.GeneratedAt = now.UTC()
+	if !cutoff.IsZero() {
+		r.Since = &cutoff
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(r)
+		return 0
+	}
+	printReport(r)
+	return 0
+}
+
+// buildReport reads the state of every issue (archived ones included) and
+// watched PR changed since cutoff, with the live state of their PRs. A PR
+// whose state cannot be fetched is reported without one.
+func buildReport(ctx context.Context, repo string, stateDir *state.Dir, cutoff time.Time) (*report, error) {
+	r := &report{Repo: repo, Issues: []reportIssue{}, PRs: []reportPR{}}
+
+	nums, err := stateDir.ListIssues()
+	if err != nil {
+		return nil, err
+	}
+	archivedNums, _ := stateDir.ListArchivedIssues()
+	archived := map[int]bool{}
+	for _, num := range archivedNums {
+		archived[num] = true
+	}
+	nums = append(nums, archivedNums...)
+	sort.Ints(nums)
+
+	ownedPRs := map[int]bool{}
+	for _, num := range nums {
+		s := stateDir.ReadIssue(num)
+		if s == nil || s.Status == state.IssuePreexisting {
+			continue
+		}
+		if s.PRNumber > 0 {
+			ownedPRs[s.PRNumber] = true
+		}
+		mod, _ := stateDir.IssueModTime(num)
+		if mod.Before(cutoff) {
+			continue
+		}
+		ri := reportIssue{
+			Issue:        num,
+			Status:       s.Status,
+			FailedReason: s.FailedReason,
+			Archived:     archived[num],
+			Labels:       s.Labels,
+			PRNumber:     s.PRNumber,
+			ReviewRounds: s.ReviewRounds,
+			InputTokens:  s.TotalInputTokens,
+			OutputTokens: s.TotalOutputTokens,
+			CostUSD:      s.TotalCostUSD,
+			UpdatedAt:    mod.UTC(),
+		}
+		if s.PRNumber > 0 {
+			ri.PRState, _ = github.GetPRState(ctx, repo, s.PRNumber)
+		}
+		r.Issues = append(r.Issues, ri)
+
+		t := &r.Totals
+		t.Issues++
+		if ri.PRNumber > 0 {
+			t.Opened++
+		}
+		if ri.PRState == "merged" {
+			t.Merged++
+		}
+		switch ri.Status {
+		case state.IssueFailed:
+			t.Failed++
+		case state.IssueInProgress, state.IssueWatching:
+			t.InFlight++
+		case state.IssueNeedsHuman:
+			t.NeedsHuman++
+		}
+		t.InputTokens += ri.InputTokens
+		t.OutputTokens += ri.OutputTokens
+		t.CostUSD += ri.CostUSD
+	}
+
+	prNums, err := stateDir.ListPRs()
+	if err != nil {
+		return nil, err
+	}
+	for _, num := range prNums {
+		if ownedPRs[num] {
+			continue
+		}
+		mod, _ := stateDir.PRModTime(num)
+		if mod.Before(cutoff) {
+			continue
+		}
+		rp := reportPR{Number: num, UpdatedAt: mod.UTC()}
+		if s := stateDir.ReadPR(num); s != nil {
+			rp.Branch = s.Branch
+		}
+		rp.State, _ = github.GetPRState(ctx, repo, num)
+		r.PRs = append(r.PRs, rp)
+	}
+	return r, nil
+}
+
+func printReport(r *report) {
+	t := r.Totals
+	fmt.Printf("auto-pr report for %s", r.Repo)
+	if r.Since != nil {
+		fmt.Printf(" since %s", r.Since.Format(time.RFC3339))
+	}
+	fmt.Println()
+	fmt.Printf("  issues: %d  opened: %d  merged: %d  failed: %d  in flight: %d  needs human: %d\n",
+		t.Issues, t.Opened, t.Merged, t.Failed, t.InFlight, t.NeedsHuman)
+	fmt.Printf("  tokens: %d in / %d out  cost: $%.4f\n", t.InputTokens, t.OutputTokens, t.CostUSD)
+	if len(r.Issues) == 0 && len(r.PRs) == 0 {
+		return
+	}
+
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ISSUE\tSTATUS\tPR\tPR STATE\tROUNDS\tCOST\tUPDATED")
+	for _, ri := range r.Issues {
+		status := string(ri.Status)
+		if ri.FailedReason != "" {
+			status += ": " + ri.FailedReason
+		}
+		pr := "-"
+		if ri.PRNumber > 0 {
+			pr = fmt.Sprintf("#%d", ri.PRNumber)
+		}
+		fmt.Fprintf(tw, "#%d\t%s\t%s\t%s\t%d\t$%.4f\t%s\n", ri.Issue, status, pr, orDash(ri.PRState), ri.ReviewRounds, ri.CostUSD, ri.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	for _, rp := range r.PRs {
+		fmt.Fprintf(tw, "-\twatched\t#%d\t%s\t\t\t%s\n", rp.Number, orDash(rp.State), rp.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	tw.Flush()
+}
+
+func printReportUsage() {
+	fmt.Println("Usage: auto-pr report [--since TIME|DURATION] [--json]")
+	fmt.Println()
+	fmt.Println("Summarizes auto-pr activity from .pr-watch-state (archived issues")
+	fmt.Println("included) with the live state of each PR on GitHub: per-issue records and")
+	fmt.Println("counts of issues, PRs opened and merged, failures, in-flight work and")
+	fmt.Println("Claude cost. Pre-existing issues are left out.")
+	fmt.Println()
+	fmt.Println("  --since   Only issues and PRs whose state changed since this RFC3339")
+	fmt.Println("            time, or this long ago (e.g. 168h)")
+	fmt.Println("  --json    Raw JSON output")
+}
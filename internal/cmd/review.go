@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"auto-pr/internal/ghcli"
+	"auto-pr/internal/github"
+)
+
+// reviewEvents maps review subcommands to GitHub review events.
+var reviewEvents = map[string]string{
+	"approve":         "APPROVE",
+	"request-changes": "REQUEST_CHANGES",
+	"comment":         "COMMENT",
+}
+
+// RunReview implements the "review" subcommand.
+func RunReview(args []string) int {
+	if len(args) == 0 {
+		printReviewUsage()
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		printReviewUsage()
+		return 0
+	}
+
+	action := args[0]
+	event, ok := reviewEvents[action]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: Unknown review action '%s'\n\n", action)
+		printReviewUsage()
+		return 1
+	}
+
+	fs := flag.NewFlagSet("review", flag.ContinueOnError)
+	body := fs.String("body", "", "Review body")
+	help := fs.Bool("help", false, "Show help")
+	h := fs.Bool("h", false, "Show help")
+
+	positional, err := parseInterleaved(fs, args[1:])
+	if err != nil {
+		return 1
+	}
+	if *help || *h {
+		printReviewUsage()
+		return 0
+	}
+	if *body == "" && event != "APPROVE" {
+		fmt.Fprintf(os.Stderr, "Error: '%s' requires --body.\n", action)
+		return 1
+	}
+	if len(positional) > 1 {
+		fmt.Fprintf(os.Stderr, "Error: Unknown argument '%s'\n", positional[1])
+		return 1
+	}
+
+	ctx := context.Background()
+
+	if err := detectGitHub(projectConfig()); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+
+	repo, err := ghcli.RepoSlug(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+
+	var prNum int
+	if len(positional) == 1 {
+		prNum, err = strconv.Atoi(positional[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid PR number '%s'\n", positional[0])
+			return 1
+		}
+	} else {
+		var branch string
+		prNum, branch, err = prForCurrentBranch(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		fmt.Printf("Detected PR #%d for branch '%s'\n", prNum, branch)
+	}
+
+	review, err := github.SubmitReview(ctx, repo, prNum, event, *body)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: Failed to submit review. Check PR number and permissions.")
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("Review submitted on PR #%d (ID: %d, %s) by @%s\n", prNum, review.ID, review.State, review.User.Login)
+	return 0
+}
+
+// parseInterleaved parses flags that may appear before, between or after
+// positional arguments (the flag package stops at the first positional one),
+// returning the positional arguments in order.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func printReviewUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  auto-pr review approve [PR_NUMBER] [--body \"...\"]        Approve a PR")
+	fmt.Println("  auto-pr review request-changes [PR_NUMBER] --body \"...\"  Request changes on a PR")
+	fmt.Println("  auto-pr review comment [PR_NUMBER] --body \"...\"          Submit a comment-only review")
+	fmt.Println("  auto-pr review --help                                    Show this help")
+	fmt.Println()
+	fmt.Println("Without PR_NUMBER, the PR for the current branch is used.")
+}
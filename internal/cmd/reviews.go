@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"auto-pr/internal/ghcli"
 	"auto-pr/internal/github"
@@ -17,6 +19,10 @@ func RunReviews(args []string) int {
 	fs := flag.NewFlagSet("reviews", flag.ContinueOnError)
 	latest := fs.Bool("latest", false, "Only show the latest review round")
 	jsonOut := fs.Bool("json", false, "Raw JSON output")
+	format := fs.String("format", "text", "Output format: text or markdown")
+	since := fs.String("since", "", "Only show comments/reviews after this time (RFC3339 or duration like 2h)")
+	by := fs.String("by", "", "Only show comments/reviews by this user")
+	reviewState := fs.String("state", "", "Only show reviews in this state, with their inline comments")
 	help := fs.Bool("help", false, "Show help")
 	h := fs.Bool("h", false, "Show help")
 
@@ -25,18 +31,49 @@ func RunReviews(args []string) int {
 	}
 
 	if *help || *h {
-		fmt.Println("Usage: auto-pr reviews [PR_NUMBER] [--latest] [--json]")
+		fmt.Println("Usage: auto-pr reviews [PR_NUMBER] [--latest] [--json] [--since T] [--by LOGIN] [--state STATE] [--format text|markdown]")
 		fmt.Println()
 		fmt.Println("  auto-pr reviews          Auto-detect PR for current branch")
 		fmt.Println("  auto-pr reviews 123      Show reviews for PR #123")
 		fmt.Println("  auto-pr reviews --latest Only show the latest review round")
 		fmt.Println("  auto-pr reviews --json   Raw JSON output")
+		fmt.Println("  auto-pr reviews --since 2h")
+		fmt.Println("                           Only show activity after a time (RFC3339) or within a duration")
+		fmt.Println("  auto-pr reviews --by alice")
+		fmt.Println("                           Only show reviews and inline comments by @alice")
+		fmt.Println("  auto-pr reviews --state CHANGES_REQUESTED")
+		fmt.Println("                           Only show reviews in a state (APPROVED, CHANGES_REQUESTED,")
+		fmt.Println("                           COMMENTED, DISMISSED, PENDING) and their inline comments")
+		fmt.Println("  auto-pr reviews --format markdown")
+		fmt.Println("                           Markdown output for pasting into issues or chat")
 		return 0
 	}
 
+	if *format != "text" && *format != "markdown" {
+		fmt.Fprintf(os.Stderr, "Error: --format must be 'text' or 'markdown', got '%s'\n", *format)
+		return 1
+	}
+
+	switch strings.ToUpper(*reviewState) {
+	case "", "APPROVED", "CHANGES_REQUESTED", "COMMENTED", "DISMISSED", "PENDING":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --state must be APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED or PENDING, got '%s'\n", *reviewState)
+		return 1
+	}
+
+	var sinceTS time.Time
+	if *since != "" {
+		ts, err := parseSince(*since, time.Now())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		sinceTS = ts
+	}
+
 	ctx := context.Background()
 
-	if err := ghcli.Detect(); err != nil {
+	if err := detectGitHub(projectConfig()); err != nil {
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		return 1
 	}
@@ -60,12 +97,8 @@ func RunReviews(args []string) int {
 
 	// Auto-detect PR from branch if not specified
 	if prNum == 0 {
-		branch, err := github.CurrentBranch()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Error:", err)
-			return 1
-		}
-		prNum, err = github.FindPRForBranch(ctx, repo, branch)
+		var branch string
+		prNum, branch, err = prForCurrentBranch(ctx, repo)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			return 1
@@ -85,6 +118,16 @@ func RunReviews(args []string) int {
 		return 1
 	}
 
+	if !sinceTS.IsZero() {
+		reviews, comments = github.FilterSince(reviews, comments, sinceTS)
+	}
+	if *by != "" {
+		reviews, comments = github.FilterByUser(reviews, comments, strings.TrimPrefix(*by, "@"))
+	}
+	if *reviewState != "" {
+		reviews, comments = github.FilterByState(reviews, comments, *reviewState)
+	}
+
 	// JSON output mode
 	if *jsonOut {
 		out := struct {
@@ -105,6 +148,11 @@ func RunReviews(args []string) int {
 		reviews, comments = github.FilterLatestReview(reviews, comments)
 	}
 
+	if *format == "markdown" {
+		printReviewsMarkdown(prNum, reviews, comments)
+		return 0
+	}
+
 	// Pretty-print
 	fmt.Println()
 	fmt.Printf("═══ PR #%d Reviews ═══\n", prNum)
@@ -134,3 +182,67 @@ func RunReviews(args []string) int {
 	fmt.Println("Done.")
 	return 0
 }
+
+// printReviewsMarkdown prints reviews as a Markdown document: a heading per
+// top-level review and a bulleted list of inline comments with quoted bodies.
+func printReviewsMarkdown(prNum int, reviews []github.Review, comments []github.ReviewComment) {
+	fmt.Printf("# PR #%d Reviews\n\n", prNum)
+
+	for _, r := range reviews {
+		if r.Body == "" && r.State == "COMMENTED" {
+			continue
+		}
+		ts := r.SubmittedAt
+		if ts == "" {
+			ts = "pending"
+		}
+		fmt.Printf("## %s by @%s (%s)\n\n", r.State, r.User.Login, ts)
+		if r.Body != "" {
+			fmt.Printf("%s\n\n", quoteMarkdown(r.Body, ""))
+		}
+	}
+
+	if len(comments) > 0 {
+		fmt.Printf("## Inline Comments (%d)\n\n", len(comments))
+		for _, c := range comments {
+			fmt.Printf("- `%s:%s` — @%s (ID: %d)\n", c.Path, c.LineDisplay(), c.User.Login, c.ID)
+			fmt.Printf("%s\n", quoteMarkdown(c.Body, "  "))
+		}
+		fmt.Println()
+	}
+}
+
+// quoteMarkdown renders s as a Markdown blockquote, each line prefixed by indent.
+func quoteMarkdown(s, indent string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(indent+"> "+l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prForCurrentBranch finds the open PR for the checked-out branch.
+func prForCurrentBranch(ctx context.Context, repo string) (int, string, error) {
+	branch, err := github.CurrentBranch()
+	if err != nil {
+		return 0, "", err
+	}
+	prNum, err := github.FindPRForBranch(ctx, repo, branch, true)
+	if err != nil {
+		return 0, branch, err
+	}
+	return prNum, branch, nil
+}
+
+// parseSince converts a --since value (RFC 3339 timestamp, or a duration
+// relative to now such as "2h") into a time.
+func parseSince(val string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since %q: expected RFC3339 time or duration like 2h", val)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"auto-pr/internal/config"
+	"auto-pr/internal/ghcli"
+	"auto-pr/internal/github"
+	"auto-pr/internal/state"
+)
+
+// treeIssue is an issue node in the tree.
+type treeIssue struct {
+	Issue    int               `json:"issue"`
+	Status   state.IssueStatus `json:"status"`
+	Reason   string            `json:"failed_reason,omitempty"`
+	Archived bool              `json:"archived,omitempty"`
+	Branch   string            `json:"branch,omitempty"`
+	Worktree string            `json:"worktree,omitempty"`
+	PR       *treePR           `json:"pr,omitempty"`
+}
+
+// treePR is a PR node in the tree.
+type treePR struct {
+	Number    int    `json:"number"`
+	State     string `json:"state,omitempty"` // live GitHub state, with --live
+	Worktree  string `json:"worktree,omitempty"`
+	CommentID int    `json:"last_comment_id,omitempty"`
+	ReviewID  int    `json:"last_review_id,omitempty"`
+}
+
+// treeView is the whole tree, as emitted by --json.
+type treeView struct {
+	Repo   string      `json:"repo,omitempty"`
+	Issues []treeIssue `json:"issues"`
+	PRs    []treePR    `json:"prs"` // watched PRs not owned by an issue worker
+	// Orphans are worktree directories no state refers to.
+	Orphans []string `json:"orphan_worktrees,omitempty"`
+}
+
+// RunTree implements the "tree" subcommand.
+func RunTree(args []string) int {
+	fs := flag.NewFlagSet("tree", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "Raw JSON output")
+	live := fs.Bool("live", false, "Also fetch current PR states from GitHub")
+	all := fs.Bool("all", false, "Include pre-existing and archived issues")
+	help := fs.Bool("help", false, "Show help")
+	h := fs.Bool("h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if *help || *h {
+		fmt.Println("Usage: auto-pr tree [--live] [--all] [--json]")
+		fmt.Println()
+		fmt.Println("  Show issues, their worktrees and PRs as a tree, from .pr-watch-state.")
+		fmt.Println("  Read-only; no GitHub calls unless --live is given.")
+		fmt.Println("  --live   Fetch each PR's current state (open/closed/merged) from GitHub")
+		fmt.Println("  --all    Include issues recorded as pre-existing, and archived ones")
+		fmt.Println("  --json   Raw JSON output")
+		return 0
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	cfg := config.Load(projectRoot)
+	stateDir := state.New(projectRoot)
+
+	view, err := buildTree(projectRoot, cfg.WorktreeDir, stateDir, *all)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+
+	if *live {
+		ctx := context.Background()
+		if err := detectGitHub(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		repo, err := ghcli.RepoSlug(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		view.Repo = repo
+		fillLiveStates(ctx, repo, view)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(view)
+		return 0
+	}
+	printTree(view, projectRoot)
+	return 0
+}
+
+// buildTree assembles the tree from state files and worktree directories.
+func buildTree(projectRoot, worktreeDir string, stateDir *state.Dir, all bool) (*treeView, error) {
+	view := &treeView{Issues: []treeIssue{}, PRs: []treePR{}}
+
+	wtRoot := filepath.Join(projectRoot, worktreeDir)
+	worktrees := map[string]bool{}
+	if entries, err := os.ReadDir(wtRoot); err == nil {
+		for _, e := range entries {
+			if e.IsDir() {
+				worktrees[e.Name()] = true
+			}
+		}
+	}
+	claim := func(name string) string {
+		if !worktrees[name] {
+			return ""
+		}
+		delete(worktrees, name)
+		return filepath.ToSlash(filepath.Join(worktreeDir, name))
+	}
+
+	issueNums, err := stateDir.ListIssues()
+	if err != nil {
+		return nil, err
+	}
+	archivedNums, _ := stateDir.ListArchivedIssues()
+	archived := map[int]bool{}
+	for _, num := range archivedNums {
+		archived[num] = true
+	}
+	issueNums = append(issueNums, archivedNums...)
+	sort.Ints(issueNums)
+
+	ownedPRs := map[int]bool{}
+	for _, num := range issueNums {
+		s := stateDir.ReadIssue(num)
+		if s == nil {
+			continue
+		}
+		if s.PRNumber > 0 {
+			ownedPRs[s.PRNumber] = true
+		}
+		if !all && (s.Status == state.IssuePreexisting || archived[num]) {
+			continue
+		}
+		node := treeIssue{
+			Issue:    num,
+			Status:   s.Status,
+			Reason:   s.FailedReason,
+			Archived: archived[num],
+			Branch:   s.Branch,
+			Worktree: claim(fmt.Sprintf("issue-%d", num)),
+		}
+		if s.PRNumber > 0 {
+			node.PR = prNode(stateDir, s.PRNumber)
+		}
+		view.Issues = append(view.Issues, node)
+	}
+
+	prNums, err := stateDir.ListPRs()
+	if err != nil {
+		return nil, err
+	}
+	for _, num := range prNums {
+		if ownedPRs[num] {
+			continue
+		}
+		node := prNode(stateDir, num)
+		node.Worktree = claim(fmt.Sprintf("pr-%d", num))
+		view.PRs = append(view.PRs, *node)
+	}
+
+	for name := range worktrees {
+		view.Orphans = append(view.Orphans, filepath.ToSlash(filepath.Join(worktreeDir, name)))
+	}
+	sort.Strings(view.Orphans)
+	return view, nil
+}
+
+func prNode(stateDir *state.Dir, num int) *treePR {
+	node := &treePR{Number: num}
+	if s := stateDir.ReadPR(num); s != nil {
+		node.CommentID = s.LastCommentID
+		node.ReviewID = s.LastReviewID
+	}
+	return node
+}
+
+// fillLiveStates fetches each PR's state from GitHub. Failures leave the
+// state empty.
+func fillLiveStates(ctx context.Context, repo string, view *treeView) {
+	for i := range view.Issues {
+		if pr := view.Issues[i].PR; pr != nil {
+			pr.State, _ = github.GetPRState(ctx, repo, pr.Number)
+		}
+	}
+	for i := range view.PRs {
+		view.PRs[i].State, _ = github.GetPRState(ctx, repo, view.PRs[i].Number)
+	}
+}
+
+func printTree(view *treeView, projectRoot string) {
+	root := view.Repo
+	if root == "" {
+		root = filepath.Base(projectRoot)
+	}
+	fmt.Println(root)
+
+	type section struct {
+		title string
+		lines [][]string // first line is the node, rest are its children
+	}
+	var sections []section
+
+	var issues section
+	issues.title = "issues"
+	for _, n := range view.Issues {
+		status := string(n.Status)
+		if n.Reason != "" {
+			status += ": " + n.Reason
+		}
+		if n.Archived {
+			status += ", archived"
+		}
+		node := []string{fmt.Sprintf("#%d [%s]", n.Issue, status)}
+		if n.Branch != "" {
+			node = append(node, "branch: "+n.Branch)
+		}
+		if n.Worktree != "" {
+			node = append(node, "worktree: "+n.Worktree)
+		}
+		if n.PR != nil {
+			node = append(node, prLabel(n.PR))
+		}
+		issues.lines = append(issues.lines, node)
+	}
+	sections = append(sections, issues)
+
+	if len(view.PRs) > 0 {
+		var prs section
+		prs.title = "watched PRs"
+		for i := range view.PRs {
+			node := []string{prLabel(&view.PRs[i])}
+			if view.PRs[i].Worktree != "" {
+				node = append(node, "worktree: "+view.PRs[i].Worktree)
+			}
+			prs.lines = append(prs.lines, node)
+		}
+		sections = append(sections, prs)
+	}
+
+	if len(view.Orphans) > 0 {
+		var orphans section
+		orphans.title = "orphan worktrees"
+		for _, o := range view.Orphans {
+			orphans.lines = append(orphans.lines, []string{o})
+		}
+		sections = append(sections, orphans)
+	}
+
+	for si, sec := range sections {
+		lastSection := si == len(sections)-1
+		fmt.Println(branchPrefix("", lastSection) + sec.title)
+		indent := childIndent("", lastSection)
+		if len(sec.lines) == 0 {
+			fmt.Println(indent + "└── (none)")
+			continue
+		}
+		for ni, node := range sec.lines {
+			lastNode := ni == len(sec.lines)-1
+			fmt.Println(branchPrefix(indent, lastNode) + node[0])
+			childInd := childIndent(indent, lastNode)
+			for ci, child := range node[1:] {
+				fmt.Println(branchPrefix(childInd, ci == len(node)-2) + child)
+			}
+		}
+	}
+}
+
+func prLabel(pr *treePR) string {
+	label := "PR #" + strconv.Itoa(pr.Number)
+	if pr.State != "" {
+		label += " [" + pr.State + "]"
+	}
+	if pr.CommentID > 0 || pr.ReviewID > 0 {
+		label += fmt.Sprintf(" (seen comment #%d / review #%d)", pr.CommentID, pr.ReviewID)
+	}
+	return label
+}
+
+func branchPrefix(indent string, last bool) string {
+	if last {
+		return indent + "└── "
+	}
+	return indent + "├── "
+}
+
+func childIndent(indent string, last bool) string {
+	if last {
+		return indent + strings.Repeat(" ", 4)
+	}
+	return indent + "│   "
+}
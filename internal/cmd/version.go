@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+
+	"auto-pr/internal/ghcli"
+)
+
+// Version is the auto-pr version, set at build time with
+// -ldflags "-X auto-pr/internal/cmd.Version=v1.2.3".
+var Version = "dev"
+
+// RunVersion implements the "version" subcommand.
+func RunVersion(args []string) int {
+	fmt.Printf("auto-pr %s\n", Version)
+	if err := ghcli.Detect(); err != nil {
+		fmt.Println("gh      not found")
+		return 0
+	}
+	fmt.Printf("gh      %s (%s, minimum %d.%d.%d)\n", ghcli.Version(), ghcli.Path(),
+		ghcli.MinVersion[0], ghcli.MinVersion[1], ghcli.MinVersion[2])
+	return 0
+}
@@ -2,20 +2,28 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"auto-pr/internal/claude"
 	"auto-pr/internal/config"
 	"auto-pr/internal/container"
 	"auto-pr/internal/ghcli"
 	"auto-pr/internal/github"
+	"auto-pr/internal/ignore"
+	"auto-pr/internal/logging"
+	"auto-pr/internal/schedule"
+	"auto-pr/internal/spec"
 	"auto-pr/internal/state"
 	"auto-pr/internal/watch"
+	"auto-pr/internal/worktree"
 )
 
 // RunWatch implements the "watch" subcommand.
@@ -37,10 +45,14 @@ func RunWatch(args []string) int {
 
 	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
 	repoMode := fs.Bool("repo", false, "Enable repo-level watching mode")
-	intervalFlag := fs.Int("interval", 0, "Poll interval in seconds")
-	maxConcurrentFlag := fs.Int("max-concurrent", 0, "Max concurrent worker processes")
-	dockerFlag := fs.Bool("docker", false, "Run workers in Docker containers for isolation")
+	issueNum := fs.Int("issue", 0, "Implement this issue and watch its PR, regardless of labels")
+	cf := addConfigFlags(fs)
 	once := fs.Bool("once", false, "Check once and exit")
+	onceFull := fs.Bool("once-full", false, "Like --once, but wait for workers to finish the review phase too")
+	serve := fs.Bool("serve", false, "Run a GitHub webhook receiver alongside polling")
+	addr := fs.String("addr", ":8080", "Listen address for --serve")
+	metricsAddr := fs.String("metrics-addr", "", "Serve Prometheus metrics on this address")
+	healthAddr := fs.String("health-addr", "", "Serve /healthz, /readyz and /status on this address (repo mode)")
 	help := fs.Bool("help", false, "Show help")
 	h := fs.Bool("h", false, "Show help")
 
@@ -53,34 +65,74 @@ func RunWatch(args []string) int {
 		fmt.Println("  auto-pr watch [PR_NUMBER] [--interval N] [--once]")
 		fmt.Println("      Single-PR mode: watch one PR (backward compatible)")
 		fmt.Println()
-		fmt.Println("  auto-pr watch --repo [--interval N] [--once] [--max-concurrent N]")
+		fmt.Println("  auto-pr watch --repo [--interval N] [--once] [--max-concurrent N] [--max-issues N]")
 		fmt.Println("      Repo mode: watch all issues with worktree isolation (spawns workers)")
 		fmt.Println()
+		fmt.Println("  auto-pr watch --issue N [--interval N] [--once]")
+		fmt.Println("      Issue mode: implement issue N (labels or not), then watch its PR")
+		fmt.Println()
 		fmt.Println("Options:")
 		fmt.Println("  --interval N        Poll interval in seconds (default: 30)")
 		fmt.Println("  --max-concurrent N  Max concurrent worker processes (default: 2)")
+		fmt.Println("  --max-issues N      Start at most N new issues per scan (MAX_ISSUES_PER_SCAN; 0 = no limit)")
 		fmt.Println("  --docker            Run workers in Docker containers for isolation")
-		fmt.Println("  --once              Check once and exit (for debugging)")
+		fmt.Println("  --once              Check once and exit (repo mode: workers implement + open a PR, then stop)")
+		fmt.Println("  --once-full         Like --once, but workers also watch their PR's reviews until it closes")
+		fmt.Println("  --serve             Receive GitHub webhooks (requires WEBHOOK_SECRET); polling continues as fallback")
+		fmt.Println("  --addr ADDR         Listen address for --serve (default: :8080)")
+		fmt.Println("  --health-addr ADDR  Serve /healthz, /readyz and /status JSON (repo mode, e.g. :9090)")
+		fmt.Println("  --metrics-addr ADDR Serve Prometheus metrics at /metrics (e.g. :9091)")
+		fmt.Println("  --set KEY=VALUE     Override a .pr-watch.conf key for this run (repeatable)")
+		fmt.Println("  --verbose           Also print debug output (polling chatter)")
+		fmt.Println("  --quiet             Only print warnings and errors")
 		fmt.Println("  --repo              Enable repo-level watching mode")
 		fmt.Println("  --help, -h          Show this help")
 		return 0
 	}
 
-	// CLI flags override config
-	interval := cfg.Interval
-	if *intervalFlag > 0 {
-		interval = *intervalFlag
+	if *onceFull {
+		*once = true
+	}
+	if *healthAddr != "" && !*repoMode {
+		fmt.Fprintln(os.Stderr, "Error: --health-addr requires --repo")
+		return 1
 	}
-	maxConcurrent := cfg.MaxConcurrent
-	if *maxConcurrentFlag > 0 {
-		maxConcurrent = *maxConcurrentFlag
+	if *issueNum != 0 {
+		switch {
+		case *issueNum < 0:
+			fmt.Fprintf(os.Stderr, "Error: --issue must be an issue number, got %d\n", *issueNum)
+			return 1
+		case *repoMode:
+			fmt.Fprintln(os.Stderr, "Error: --issue and --repo are mutually exclusive")
+			return 1
+		case fs.NArg() > 0:
+			fmt.Fprintf(os.Stderr, "Error: Unknown argument '%s'\n", fs.Arg(0))
+			return 1
+		}
 	}
 
-	// Determine Docker mode: CLI flag overrides config
-	dockerEnabled := cfg.DockerEnabled || *dockerFlag
+	// --set overrides win over .pr-watch.conf, dedicated flags over both
+	if err := cf.apply(&cfg, nil); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	interval, maxConcurrent, dockerEnabled := cfg.Interval, cfg.MaxConcurrent, cfg.DockerEnabled
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	logging.SetLevel(level)
 
 	// Detect tools
-	if err := ghcli.Detect(); err != nil {
+	if err := detectGitHub(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	worktree.SetShallow(cfg.WorktreeShallow)
+	mcpConfig, err := mcpConfigPath(projectRoot, cfg.ClaudeMCPConfig)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	systemPrompt, err := appendSystemPrompt(projectRoot, cfg.ClaudeAppendSystemPrompt)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		return 1
 	}
@@ -99,7 +151,29 @@ func RunWatch(args []string) int {
 			fmt.Fprintln(os.Stderr, "Error:", err)
 			return 1
 		}
+		if _, err := container.ClaudeAuth(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
 		dockerMgr = container.NewManager(cfg.DockerImage, projectRoot, cfg.DockerFile)
+		if dockerMgr.User, err = container.ResolveUser(cfg.DockerUser); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		dockerMgr.MountSSH = cfg.DockerMountSSH
+		dockerMgr.Pull = cfg.DockerImagePull
+		dockerMgr.Network = cfg.DockerNetwork
+		dockerMgr.MCPConfig = mcpConfig
+		if cfg.DockerNetwork == "none" {
+			logging.Warnf("[auto-pr] Warning: DOCKER_NETWORK=none: gh and claude cannot reach GitHub or the Anthropic API from worker containers")
+		}
+
+		// Fallback to local runs needs the claude CLI on the host
+		if cfg.DockerFallbackLocal {
+			if err := claude.Detect(); err != nil {
+				logging.Warnf("[auto-pr] Warning: DOCKER_FALLBACK_LOCAL is set but %v", err)
+			}
+		}
 	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
@@ -117,6 +191,39 @@ func RunWatch(args []string) int {
 		fmt.Fprintln(os.Stderr, "Error initializing state:", err)
 		return 1
 	}
+	stateDir.OnTransition = watch.EventHook(cfg.OnEventCommand, repo)
+
+	// Stop everything if GitHub authentication is lost mid-run
+	go func() {
+		select {
+		case <-ghcli.AuthLostC():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Start the webhook receiver; events wake the same loops polling drives
+	var notifier *watch.Notifier
+	if *serve {
+		if cfg.WebhookSecret == "" {
+			fmt.Fprintln(os.Stderr, "Error: --serve requires WEBHOOK_SECRET in .pr-watch.conf")
+			return 1
+		}
+		notifier = watch.NewNotifier()
+		go func() {
+			if err := watch.Serve(ctx, *addr, cfg.WebhookSecret, notifier); err != nil {
+				cancel()
+			}
+		}()
+	}
+
+	if *metricsAddr != "" {
+		go func() {
+			if err := watch.ServeMetrics(ctx, *metricsAddr); err != nil {
+				cancel()
+			}
+		}()
+	}
 
 	// Ensure .gitignore covers state and worktree dirs
 	state.EnsureGitignore(projectRoot, []string{
@@ -124,15 +231,121 @@ func RunWatch(args []string) int {
 		cfg.WorktreeDir + "/",
 	})
 
+	ign, err := ignore.Load(projectRoot)
+	if err != nil {
+		logging.Warnf("[auto-pr] Warning: %s not applied: %v", ignore.FileName, err)
+	}
+	extraArgs, _ := config.SplitWords(cfg.ClaudeExtraArgs) // validated by config.Set
+	var workHours *schedule.Schedule
+	if cfg.WorkHours != "" {
+		if workHours, err = schedule.Parse(cfg.WorkHours, cfg.Timezone); err != nil {
+			fmt.Fprintln(os.Stderr, "Error: WORK_HOURS:", err)
+			return 1
+		}
+	}
+	prompts, err := watch.LoadPrompts(projectRoot)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: prompt template:", err)
+		return 1
+	}
+	if names := prompts.Names(); len(names) > 0 {
+		logging.Infof("[auto-pr] Using prompt templates from %s: %s", watch.PromptDir, strings.Join(names, ", "))
+	}
+
+	wcfg := watch.WorkerConfig{
+		WorktreeDir:   cfg.WorktreeDir,
+		BaseBranch:    cfg.BaseBranch,
+		IssueLabels:   cfg.IssueLabels,
+		DockerEnabled: dockerEnabled,
+		DockerImage:   cfg.DockerImage,
+		CommitTrailer: cfg.CommitTrailer,
+		DedupComments: cfg.DedupComments,
+
+		UploadLogOnFailure:  cfg.UploadLogOnFailure,
+		DockerStartTimeout:  cfg.DockerStartTimeout,
+		DockerFallbackLocal: cfg.DockerFallbackLocal,
+
+		IssueExcludeLabels:   cfg.IssueExcludeLabels,
+		PriorityLabels:       cfg.PriorityLabels,
+		AutoApplySuggestions: cfg.AutoApplySuggestions,
+		AutoMerge:            cfg.AutoMerge,
+		WatchCI:              cfg.WatchCI,
+		CIFixAttempts:        cfg.CIFixAttempts,
+		AutoRebase:           cfg.AutoRebase,
+		AutoRebaseAttempts:   cfg.AutoRebaseAttempts,
+		BaseMismatch:         cfg.BaseMismatch,
+		ConflictAction:       cfg.ConflictAction,
+		ClaudeVerbose:        cfg.ClaudeVerbose,
+		ClaudeAllowedTools:   cfg.ClaudeAllowedTools,
+		ClaudePermissionMode: cfg.ClaudePermissionMode,
+		ClaudeExtraArgs:      extraArgs,
+		ClaudeMCPConfig:      mcpConfig,
+		TriggerComment:       cfg.TriggerComment,
+		TriggerUsers:         cfg.TriggerUsers,
+		BotLogin:             botLogin(ctx, cfg.BotLogin),
+		MaxReviewRounds:      cfg.MaxReviewRounds,
+		PRCommands:           cfg.PRCommands,
+		CommandUsers:         cfg.CommandUsers,
+		MaxCommentsPerRound:  cfg.MaxCommentsPerRound,
+		MinIssueBodyChars:    cfg.MinIssueBodyChars,
+		AdaptiveConcurrency:  cfg.AdaptiveConcurrency,
+		MinConcurrent:        cfg.MinConcurrent,
+		CompactAfterRounds:   cfg.CompactAfterRounds,
+		CompactInputTokens:   cfg.CompactInputTokens,
+		SpecFetcher:          spec.NewFetcher(cfg.SpecURLAllowlist, int64(cfg.SpecMaxBytes), time.Duration(cfg.SpecFetchTimeout)*time.Second),
+		Ignore:               ign,
+		EditScope:            cfg.EditScope,
+		FormatCommand:        cfg.FormatCommand,
+		Prompts:              prompts,
+		ShutdownTimeout:      cfg.ShutdownTimeout,
+		MaxIssuesPerScan:     cfg.MaxIssuesPerScan,
+		WorkHours:            workHours,
+		WorkerTimeout:        cfg.WorkerTimeout,
+		ReviewTimeout:        cfg.ReviewTimeout,
+		MaxPromptBodyBytes:   cfg.MaxPromptBodyBytes,
+		PRDraft:              cfg.PRDraft,
+		PRReviewers:          cfg.PRReviewers,
+		PRLabels:             cfg.PRLabels,
+		PRDoneLabel:          cfg.PRDoneLabel,
+		CloseIssueOnMerge:    cfg.CloseIssueOnMerge,
+		LogMaxSizeMB:         cfg.LogMaxSizeMB,
+		LogMaxBackups:        cfg.LogMaxBackups,
+		ArchiveAfter:         cfg.ArchiveAfter,
+		OnceFull:             *onceFull,
+
+		InsufficientDetailComment: cfg.InsufficientDetailComment,
+		IssueProgressComments:     cfg.IssueProgressComments,
+		ClaudeAppendSystemPrompt:  systemPrompt,
+		WorkHoursPauseReviews:     cfg.WorkHoursPauseReviews,
+	}
+
 	if *repoMode {
-		wcfg := watch.WorkerConfig{
-			WorktreeDir:   cfg.WorktreeDir,
-			BaseBranch:    cfg.BaseBranch,
-			IssueLabels:   cfg.IssueLabels,
-			DockerEnabled: dockerEnabled,
-			DockerImage:   cfg.DockerImage,
-		}
-		err := watch.Repo(ctx, repo, projectRoot, interval, maxConcurrent, *once, wcfg, stateDir, dockerMgr)
+		if *healthAddr != "" {
+			wcfg.Health = watch.NewHealth(stateDir, interval)
+			go func() {
+				if err := watch.ServeHealth(ctx, *healthAddr, wcfg.Health); err != nil {
+					cancel()
+				}
+			}()
+		}
+		err := watch.Repo(ctx, repo, projectRoot, interval, maxConcurrent, *once, wcfg, stateDir, dockerMgr, notifier)
+		if ghcli.AuthLost() {
+			fmt.Fprintln(os.Stderr, "Error:", ghcli.ErrAuthLost)
+			return 1
+		}
+		if err != nil && err != context.Canceled {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		return 0
+	}
+
+	if *issueNum > 0 {
+		err := watch.Issue(ctx, repo, projectRoot, *issueNum, interval, *once, wcfg, stateDir, dockerMgr, notifier)
+		if ghcli.AuthLost() {
+			fmt.Fprintln(os.Stderr, "Error:", ghcli.ErrAuthLost)
+			return 1
+		}
 		if err != nil && err != context.Canceled {
 			fmt.Fprintln(os.Stderr, "Error:", err)
 			return 1
@@ -157,15 +370,19 @@ func RunWatch(args []string) int {
 			fmt.Fprintln(os.Stderr, "Error:", err)
 			return 1
 		}
-		prNum, err = github.FindPRForBranch(ctx, repo, branch)
+		prNum, err = github.FindPRForBranch(ctx, repo, branch, true)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			return 1
 		}
-		fmt.Printf("Detected PR #%d for branch '%s'\n", prNum, branch)
+		logging.Infof("Detected PR #%d for branch '%s'", prNum, branch)
 	}
 
-	err = watch.SinglePR(ctx, repo, projectRoot, prNum, interval, *once, stateDir, dockerMgr)
+	err = watch.SinglePR(ctx, repo, projectRoot, prNum, interval, *once, wcfg, stateDir, dockerMgr, notifier)
+	if ghcli.AuthLost() {
+		fmt.Fprintln(os.Stderr, "Error:", ghcli.ErrAuthLost)
+		return 1
+	}
 	if err != nil && err != context.Canceled {
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		return 1
@@ -173,6 +390,32 @@ func RunWatch(args []string) int {
 	return 0
 }
 
+// botLogin resolves BOT_LOGIN: empty means the gh-authenticated user,
+// "none" disables filtering.
+func botLogin(ctx context.Context, login string) string {
+	switch login {
+	case "none":
+		return ""
+	case "":
+		login, err := github.CurrentUser(ctx)
+		if err != nil {
+			logging.Warnf("[auto-pr] Warning: could not determine BOT_LOGIN, the bot's own comments will not be filtered: %v", err)
+		}
+		return login
+	}
+	return login
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
 func findProjectRoot() (string, error) {
 	// Use current working directory, then walk up to find .git
 	dir, err := os.Getwd()
@@ -192,3 +435,60 @@ func findProjectRoot() (string, error) {
 	// Fallback to cwd
 	return os.Getwd()
 }
+
+// detectGitHub finds gh and points it at the host and API prefix from cfg.
+func detectGitHub(cfg config.Config) error {
+	if err := ghcli.Detect(); err != nil {
+		return err
+	}
+	ghcli.SetHost(cfg.GitHubHost)
+	github.SetAPIPrefix(cfg.GitHubAPIPrefix)
+	return nil
+}
+
+// mcpConfigPath resolves CLAUDE_MCP_CONFIG against the project root and
+// checks that it is a readable JSON file, so a typo fails at startup rather
+// than in every Claude run. Returns "" when it is not set.
+func mcpConfigPath(projectRoot, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectRoot, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("CLAUDE_MCP_CONFIG: %w", err)
+	}
+	if !json.Valid(data) {
+		return "", fmt.Errorf("CLAUDE_MCP_CONFIG: %s is not valid JSON", path)
+	}
+	return path, nil
+}
+
+// appendSystemPrompt resolves CLAUDE_APPEND_SYSTEM_PROMPT: "@path" is read
+// from a file (relative to the project root), anything else is used as is.
+func appendSystemPrompt(projectRoot, val string) (string, error) {
+	path, ok := strings.CutPrefix(val, "@")
+	if !ok {
+		return val, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectRoot, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("CLAUDE_APPEND_SYSTEM_PROMPT: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// projectConfig loads .pr-watch.conf for commands that otherwise do not need
+// one, so they reach the same GitHub host as watch.
+func projectConfig() config.Config {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return config.DefaultConfig()
+	}
+	return config.Load(projectRoot)
+}
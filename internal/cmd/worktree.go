@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"auto-pr/internal/config"
+	"auto-pr/internal/ghcli"
+	"auto-pr/internal/state"
+	"auto-pr/internal/watch"
+	"auto-pr/internal/worktree"
+)
+
+// RunWorktree implements the "worktree" subcommand.
+func RunWorktree(args []string) int {
+	if len(args) == 0 {
+		printWorktreeUsage()
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		printWorktreeUsage()
+		return 0
+	}
+	action := args[0]
+	if action != "list" && action != "prune" {
+		fmt.Fprintf(os.Stderr, "Error: Unknown worktree action '%s'\n\n", action)
+		printWorktreeUsage()
+		return 1
+	}
+
+	fs := flag.NewFlagSet("worktree", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "Raw JSON output (list)")
+	dryRun := fs.Bool("dry-run", false, "Show what would be removed (prune)")
+	help := fs.Bool("help", false, "Show help")
+	h := fs.Bool("h", false, "Show help")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 1
+	}
+	if *help || *h {
+		printWorktreeUsage()
+		return 0
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: Unknown argument '%s'\n", fs.Arg(0))
+		return 1
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	cfg := config.Load(projectRoot)
+
+	ctx := context.Background()
+	if err := detectGitHub(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	repo, err := ghcli.RepoSlug(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+
+	wts := watch.ListWorktrees(ctx, repo, projectRoot, cfg.WorktreeDir, state.New(projectRoot))
+	for i := range wts {
+		if rel, err := filepath.Rel(projectRoot, wts[i].Path); err == nil {
+			wts[i].Path = filepath.ToSlash(rel)
+		}
+	}
+
+	if action == "list" {
+		if *jsonOut {
+			if wts == nil {
+				wts = []watch.Worktree{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(wts)
+			return 0
+		}
+		if len(wts) == 0 {
+			fmt.Printf("No worktrees under %s.\n", cfg.WorktreeDir)
+			return 0
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "WORKTREE\tBRANCH\tOWNER\tLOCAL\tGITHUB\t")
+		for _, wt := range wts {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", wt.Path, orDash(wt.Branch), worktreeOwner(wt), orDash(string(wt.Status)), orDash(wt.Remote), orphanMark(wt))
+		}
+		tw.Flush()
+		return 0
+	}
+
+	// prune
+	removed, failed := 0, 0
+	for _, wt := range wts {
+		if !wt.Orphaned {
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("Would remove %s (%s, %s)\n", wt.Path, worktreeOwner(wt), wt.Remote)
+			removed++
+			continue
+		}
+		if err := worktree.Remove(projectRoot, filepath.Join(projectRoot, wt.Path)); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			failed++
+			continue
+		}
+		fmt.Printf("Removed %s (%s, %s)\n", wt.Path, worktreeOwner(wt), wt.Remote)
+		removed++
+	}
+	switch {
+	case removed == 0 && failed == 0:
+		fmt.Println("No orphaned worktrees.")
+	case *dryRun:
+		fmt.Printf("%d worktree(s) would be removed.\n", removed)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// worktreeOwner describes the issue or PR a worktree belongs to.
+func worktreeOwner(wt watch.Worktree) string {
+	switch wt.Kind {
+	case "issue":
+		return fmt.Sprintf("issue #%d", wt.Number)
+	case "pr":
+		return fmt.Sprintf("PR #%d", wt.Number)
+	}
+	return "-"
+}
+
+func orphanMark(wt watch.Worktree) string {
+	if wt.Orphaned {
+		return "orphaned"
+	}
+	return ""
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func printWorktreeUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  auto-pr worktree list [--json]      Show worktrees with their branch, issue/PR and state")
+	fmt.Println("  auto-pr worktree prune [--dry-run]  Remove orphaned worktrees")
+	fmt.Println("  auto-pr worktree --help             Show this help")
+	fmt.Println()
+	fmt.Println("A worktree is orphaned when its issue is closed (and no worker is active")
+	fmt.Println("on it) or its PR is closed or merged. Directories auto-pr did not create")
+	fmt.Println("are listed but never pruned.")
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Entry is one key of the effective configuration.
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// Entries lists every key with its effective value, in .pr-watch.conf order.
+// Secrets are shown as "(set)".
+func (c Config) Entries() []Entry {
+	b := strconv.FormatBool
+	i := strconv.Itoa
+	secret := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "(set)"
+	}
+	return []Entry{
+		{"MAX_CONCURRENT", i(c.MaxConcurrent)},
+		{"INTERVAL", i(c.Interval)},
+		{"ISSUE_LABELS", c.IssueLabels},
+		{"ISSUE_EXCLUDE_LABELS", c.IssueExcludeLabels},
+		{"PRIORITY_LABELS", c.PriorityLabels},
+		{"WORKTREE_DIR", c.WorktreeDir},
+		{"WORKTREE_SHALLOW", b(c.WorktreeShallow)},
+		{"BASE_BRANCH", c.BaseBranch},
+		{"DOCKER", b(c.DockerEnabled)},
+		{"DOCKER_IMAGE", c.DockerImage},
+		{"DOCKER_FILE", c.DockerFile},
+		{"DOCKER_START_TIMEOUT", i(c.DockerStartTimeout)},
+		{"DOCKER_FALLBACK_LOCAL", b(c.DockerFallbackLocal)},
+		{"DOCKER_USER", c.DockerUser},
+		{"DOCKER_MOUNT_SSH", b(c.DockerMountSSH)},
+		{"DOCKER_IMAGE_PULL", b(c.DockerImagePull)},
+		{"DOCKER_NETWORK", c.DockerNetwork},
+		{"COMMIT_TRAILER", c.CommitTrailer},
+		{"DEDUP_COMMENTS", b(c.DedupComments)},
+		{"UPLOAD_LOG_ON_FAILURE", b(c.UploadLogOnFailure)},
+		{"WEBHOOK_SECRET", secret(c.WebhookSecret)},
+		{"AUTO_APPLY_SUGGESTIONS", b(c.AutoApplySuggestions)},
+		{"AUTO_MERGE", c.AutoMerge},
+		{"SPEC_URL_ALLOWLIST", c.SpecURLAllowlist},
+		{"SPEC_MAX_BYTES", i(c.SpecMaxBytes)},
+		{"SPEC_FETCH_TIMEOUT", i(c.SpecFetchTimeout)},
+		{"WATCH_CI", b(c.WatchCI)},
+		{"CI_FIX_ATTEMPTS", i(c.CIFixAttempts)},
+		{"AUTO_REBASE", b(c.AutoRebase)},
+		{"AUTO_REBASE_ATTEMPTS", i(c.AutoRebaseAttempts)},
+		{"BASE_MISMATCH", c.BaseMismatch},
+		{"CONFLICT_ACTION", c.ConflictAction},
+		{"CLAUDE_VERBOSE", c.ClaudeVerbose},
+		{"CLAUDE_ALLOWED_TOOLS", c.ClaudeAllowedTools},
+		{"CLAUDE_PERMISSION_MODE", c.ClaudePermissionMode},
+		{"CLAUDE_EXTRA_ARGS", c.ClaudeExtraArgs},
+		{"CLAUDE_MCP_CONFIG", c.ClaudeMCPConfig},
+		{"CLAUDE_APPEND_SYSTEM_PROMPT", c.ClaudeAppendSystemPrompt},
+		{"TRIGGER_COMMENT", c.TriggerComment},
+		{"TRIGGER_USERS", c.TriggerUsers},
+		{"BOT_LOGIN", c.BotLogin},
+		{"MAX_REVIEW_ROUNDS", i(c.MaxReviewRounds)},
+		{"PR_COMMANDS", b(c.PRCommands)},
+		{"COMMAND_USERS", c.CommandUsers},
+		{"MAX_COMMENTS_PER_ROUND", i(c.MaxCommentsPerRound)},
+		{"MIN_ISSUE_BODY_CHARS", i(c.MinIssueBodyChars)},
+		{"INSUFFICIENT_DETAIL_COMMENT", c.InsufficientDetailComment},
+		{"GITHUB_HOST", c.GitHubHost},
+		{"GITHUB_API_PREFIX", c.GitHubAPIPrefix},
+		{"LOG_LEVEL", c.LogLevel},
+		{"ADAPTIVE_CONCURRENCY", b(c.AdaptiveConcurrency)},
+		{"MIN_CONCURRENT", i(c.MinConcurrent)},
+		{"COMPACT_AFTER_ROUNDS", i(c.CompactAfterRounds)},
+		{"COMPACT_INPUT_TOKENS", i(c.CompactInputTokens)},
+		{"EDIT_SCOPE", c.EditScope},
+		{"FORMAT_COMMAND", c.FormatCommand},
+		{"ISSUE_PROGRESS_COMMENTS", b(c.IssueProgressComments)},
+		{"ON_EVENT_COMMAND", c.OnEventCommand},
+		{"SHUTDOWN_TIMEOUT", i(c.ShutdownTimeout)},
+		{"MAX_ISSUES_PER_SCAN", i(c.MaxIssuesPerScan)},
+		{"WORK_HOURS", c.WorkHours},
+		{"TIMEZONE", c.Timezone},
+		{"WORK_HOURS_PAUSE_REVIEWS", b(c.WorkHoursPauseReviews)},
+		{"WORKER_TIMEOUT", i(c.WorkerTimeout)},
+		{"REVIEW_TIMEOUT", i(c.ReviewTimeout)},
+		{"MAX_PROMPT_BODY_BYTES", i(c.MaxPromptBodyBytes)},
+		{"PR_DRAFT", b(c.PRDraft)},
+		{"PR_REVIEWERS", c.PRReviewers},
+		{"PR_LABELS", c.PRLabels},
+		{"PR_DONE_LABEL", c.PRDoneLabel},
+		{"CLOSE_ISSUE_ON_MERGE", b(c.CloseIssueOnMerge)},
+		{"LOG_MAX_SIZE_MB", i(c.LogMaxSizeMB)},
+		{"LOG_MAX_BACKUPS", i(c.LogMaxBackups)},
+		{"ARCHIVE_AFTER", i(c.ArchiveAfter)},
+	}
+}
+
+// Check reports combinations of values that are individually valid but do
+// not make sense together.
+func (c Config) Check() []string {
+	var problems []string
+	if c.AdaptiveConcurrency && c.MinConcurrent > c.MaxConcurrent {
+		problems = append(problems, fmt.Sprintf("MIN_CONCURRENT (%d) is above MAX_CONCURRENT (%d); MAX_CONCURRENT is used", c.MinConcurrent, c.MaxConcurrent))
+	}
+	if c.DockerImagePull && c.DockerFile != "" {
+		problems = append(problems, "DOCKER_FILE is only used by 'auto-pr image build' while DOCKER_IMAGE_PULL is set")
+	}
+	if c.DockerNetwork == "none" {
+		problems = append(problems, `DOCKER_NETWORK="none" cuts workers off from GitHub and the Claude API`)
+	}
+	if c.InsufficientDetailComment != "" && c.MinIssueBodyChars == 0 {
+		problems = append(problems, "INSUFFICIENT_DETAIL_COMMENT has no effect while MIN_ISSUE_BODY_CHARS is 0")
+	}
+	if c.TriggerUsers != "" && c.TriggerComment == "" {
+		problems = append(problems, "TRIGGER_USERS has no effect without TRIGGER_COMMENT")
+	}
+	if c.CommandUsers != "" && !c.PRCommands {
+		problems = append(problems, "COMMAND_USERS has no effect while PR_COMMANDS is off")
+	}
+	if c.ClaudePermissionMode == "bypassPermissions" && !c.DockerEnabled {
+		problems = append(problems, "CLAUDE_PERMISSION_MODE=bypassPermissions without DOCKER lets Claude run any command on this machine")
+	}
+	if c.WorkHours == "" && (c.Timezone != "" || c.WorkHoursPauseReviews) {
+		problems = append(problems, "TIMEZONE and WORK_HOURS_PAUSE_REVIEWS have no effect without WORK_HOURS")
+	}
+	return problems
+}
@@ -2,22 +2,118 @@ package config
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"auto-pr/internal/schedule"
 )
 
 // Config holds pr-watch configuration.
 type Config struct {
-	MaxConcurrent int
-	Interval      int
-	IssueLabels   string
-	WorktreeDir   string
-	BaseBranch    string
-	DockerEnabled bool
-	DockerImage   string
-	DockerFile    string // explicit Dockerfile path (DOCKER_FILE config key)
+	MaxConcurrent      int
+	Interval           int
+	IssueLabels        string
+	IssueExcludeLabels string // issues carrying any of these labels are skipped
+	PriorityLabels     string // issues with earlier labels in this list are picked up first
+	WorktreeDir        string
+	WorktreeShallow    bool // fetch with --depth 1 when creating worktrees
+	BaseBranch         string
+	DockerEnabled      bool
+	DockerImage        string
+	DockerFile         string // explicit Dockerfile path (DOCKER_FILE config key)
+	CommitTrailer      string // git trailer appended to automated commits ("" disables)
+	DedupComments      bool   // skip comments identical to ones already handled
+
+	UploadLogOnFailure bool   // upload failed worker logs to a secret gist
+	WebhookSecret      string // shared secret for watch --serve webhook signatures
+
+	DockerStartTimeout  int    // seconds to wait for a worker container to start (0 = no limit)
+	ShutdownTimeout     int    // seconds to wait for workers on Ctrl-C before abandoning them (0 = no limit)
+	MaxIssuesPerScan    int    // new issues started per scan (0 = no limit)
+	WorkerTimeout       int    // seconds a worker may spend implementing its issue (0 = no limit)
+	ReviewTimeout       int    // seconds a worker may watch its PR's reviews (0 = no limit)
+	MaxPromptBodyBytes  int    // issue body / review data size before it is trimmed for prompts (0 = no limit)
+	PRDraft             bool   // open worker PRs as drafts, marked ready once checks pass
+	PRReviewers         string // comma-separated logins and org/team slugs asked to review worker PRs
+	PRLabels            string // comma-separated labels added to worker PRs once opened
+	PRDoneLabel         string // label added to a worker PR when its worker is done
+	CloseIssueOnMerge   bool   // close the originating issue when its PR merges
+	LogMaxSizeMB        int    // rotate a worker log once it reaches this size (0 = never)
+	LogMaxBackups       int    // rotated worker logs kept per issue
+	ArchiveAfter        int    // seconds after which done/failed issue state is archived (0 = never)
+	DockerFallbackLocal bool   // run Claude on the host when the container cannot start
+	DockerUser          string // user worker containers run as: "" (root), "host" or "UID[:GID]"
+	DockerMountSSH      bool   // mount ~/.ssh read-only and forward SSH_AUTH_SOCK into containers
+	DockerImagePull     bool   // pull DOCKER_IMAGE from its registry instead of building it
+	DockerNetwork       string // docker --network for worker containers ("" = default bridge)
+
+	AutoApplySuggestions bool   // commit reviewers' suggestion blocks without Claude
+	AutoMerge            string // merge approved, green PRs: "squash", "merge", "rebase" ("" = off)
+
+	SpecURLAllowlist string // comma-separated domains whose linked docs are fetched for issues
+	SpecMaxBytes     int    // max bytes read per fetched doc
+	SpecFetchTimeout int    // seconds per fetch
+
+	WatchCI       bool // feed failing CI checks on worker PRs back to Claude
+	CIFixAttempts int  // max consecutive CI fix attempts per PR
+
+	AutoRebase         bool // keep worker PRs rebased onto their base branch
+	AutoRebaseAttempts int  // max consecutive conflict resolutions by Claude per PR
+
+	BaseMismatch   string // when a PR's base differs from its worktree's: "warn" or "rebase"
+	ConflictAction string // overlapping comments from different reviewers: "prompt" or "pause"
+	ClaudeVerbose  string // phases run with claude --verbose: "all", "implement", "review", "none"
+
+	ClaudeAllowedTools   string // claude --allowedTools, e.g. "Edit Bash(git:*)" ("" = claude's default)
+	ClaudePermissionMode string // claude --permission-mode ("" = claude's default)
+	ClaudeExtraArgs      string // extra claude flags, split like a shell command line
+	ClaudeMCPConfig      string // MCP config file passed as --mcp-config (relative to the project root)
+
+	ClaudeAppendSystemPrompt string // text for --append-system-prompt, or "@file" (relative to the project root)
+
+	TriggerComment string // issue comment that starts work on a labeled issue ("" = start on label)
+	TriggerUsers   string // logins allowed to post the trigger ("" = repo owners, members, collaborators)
+
+	BotLogin string // login whose comments are ignored ("" = the gh-authenticated user, "none" = nobody)
+
+	MaxReviewRounds int // review rounds handed to Claude per PR before asking for a human (0 = no limit)
+
+	PRCommands   bool   // act on "/auto-pr <command>" comments on worker PRs
+	CommandUsers string // logins allowed to issue commands ("" = repo owners, members, collaborators)
+
+	MaxCommentsPerRound int // inline comments per Claude run; more are handled in batches (0 = no limit)
+
+	MinIssueBodyChars         int    // issues with shorter bodies are skipped as insufficient_detail (0 = off)
+	InsufficientDetailComment string // comment asking the reporter for detail ("" = don't comment)
+
+	GitHubHost      string // GitHub host gh talks to, e.g. a GitHub Enterprise server ("" = gh's default)
+	GitHubAPIPrefix string // path prefix for REST API calls, for Enterprise installs behind a subpath or proxy
+
+	LogLevel string // console verbosity of watch: "debug", "info", "warn", "error"
+
+	AdaptiveConcurrency bool // scale concurrency with system load between MIN_CONCURRENT and MAX_CONCURRENT
+	MinConcurrent       int  // lower bound for ADAPTIVE_CONCURRENCY
+
+	WorkHours             string // when new issues are started, e.g. "Mon-Fri 09:00-18:00" ("" = always)
+	Timezone              string // IANA zone WORK_HOURS is in ("" = local)
+	WorkHoursPauseReviews bool   // also hold review handling outside WORK_HOURS
+
+	CompactAfterRounds int // start a fresh, summarized Claude session every N review rounds (0 = off)
+	CompactInputTokens int // ... or once a review run's input tokens reach this (0 = off)
+
+	EditScope string // files changed outside the review comments: "off", "revert" or "comment" (revert and say so)
+
+	FormatCommand string // run on the files Claude changed, e.g. "gofmt -w" ("" = off)
+
+	IssueProgressComments bool // comment on the issue when work starts and when its PR is opened
+
+	OnEventCommand string // shell command run (detached) on every issue status change ("" = off)
 }
 
 // DefaultConfig returns the default configuration.
@@ -30,9 +126,47 @@ func DefaultConfig() Config {
 		BaseBranch:    "",
 		DockerEnabled: false,
 		DockerImage:   "auto-pr-worker",
+		CommitTrailer: DefaultCommitTrailer,
+
+		DockerStartTimeout: 120,
+		ShutdownTimeout:    30,
+		MaxPromptBodyBytes: 64 * 1024,
+		LogMaxBackups:      3,
+		ArchiveAfter:       7 * 24 * 60 * 60,
+
+		SpecMaxBytes:     100 * 1024,
+		SpecFetchTimeout: 10,
+
+		CIFixAttempts:      3,
+		AutoRebaseAttempts: 2,
+		BaseMismatch:       "warn",
+
+		ConflictAction: "prompt",
+		ClaudeVerbose:  "all",
+
+		LogLevel: "info",
+
+		MinConcurrent: 1,
+
+		EditScope: "revert",
+
+		IssueProgressComments: true,
+		CloseIssueOnMerge:     true,
 	}
 }
 
+// DefaultCommitTrailer tags commits created by auto-pr workers.
+const DefaultCommitTrailer = "Generated-by: auto-pr"
+
+// trailerRE matches a git trailer line: "Token: value", where the token is
+// alphanumeric with dashes (e.g. "Generated-by", "X-Auto-PR", "Co-authored-by").
+var trailerRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: \S.*$`)
+
+// ValidTrailer reports whether s is a well-formed git trailer line.
+func ValidTrailer(s string) bool {
+	return trailerRE.MatchString(s) && !strings.ContainsAny(s, "\r\n")
+}
+
 const defaultConfTemplate = `# auto-pr watch configuration
 # Uncomment and edit values as needed. Defaults are shown.
 
@@ -45,9 +179,21 @@ const defaultConfTemplate = `# auto-pr watch configuration
 # Issue labels that trigger auto-processing (comma-separated, OR logic)
 # ISSUE_LABELS="auto,claude"
 
+# Skip issues that also carry any of these labels (comma-separated)
+# ISSUE_EXCLUDE_LABELS="wontfix,blocked"
+
+# When slots are scarce, pick up issues carrying earlier labels first
+# (then oldest first)
+# PRIORITY_LABELS="p0,p1,p2"
+
 # Directory for git worktrees
 # WORKTREE_DIR=".worktrees"
 
+# Fetch only the tip of branches (--depth 1) when creating worktrees, for huge
+# repos. Makes the repository shallow: Claude sees little history (git log,
+# blame). Clone with --filter=blob:none to also skip old file contents
+# WORKTREE_SHALLOW=false
+
 # Base branch for new issue branches (default: repo default branch)
 # BASE_BRANCH="main"
 
@@ -60,12 +206,273 @@ const defaultConfTemplate = `# auto-pr watch configuration
 # Custom Dockerfile path (default: auto-resolve)
 # Lookup order: DOCKER_FILE -> {repo}/Dockerfile.autopr -> embedded default
 # DOCKER_FILE=""
+
+# Git trailer appended to every automated commit ("Token: value"; empty disables)
+# COMMIT_TRAILER="Generated-by: auto-pr"
+
+# Skip review comments identical (same path, line and body) to ones already handled
+# DEDUP_COMMENTS=false
+
+# Upload a failed worker's log (secrets redacted) to a secret gist
+# UPLOAD_LOG_ON_FAILURE=false
+
+# Secret configured on the GitHub webhook (required for watch --serve)
+# WEBHOOK_SECRET=""
+
+# Max wait for a worker container to start (seconds or duration like 2m; 0 = no limit)
+# DOCKER_START_TIMEOUT=120
+
+# If the image or container cannot be started, run Claude on the host instead
+# (requires the claude CLI on the host)
+# DOCKER_FALLBACK_LOCAL=false
+
+# Commit reviewers' single-line suggestion blocks directly instead of asking
+# Claude (falls back to Claude when a suggestion cannot be applied)
+# AUTO_APPLY_SUGGESTIONS=false
+
+# Merge a worker's PR once it is approved, has no outstanding change requests
+# and all checks pass: squash, merge or rebase (empty = off)
+# AUTO_MERGE=""
+
+# Fetch https docs linked from an issue body when their domain is listed here
+# (comma-separated; subdomains included) and give them to Claude as context
+# SPEC_URL_ALLOWLIST=""
+# SPEC_MAX_BYTES=102400
+# SPEC_FETCH_TIMEOUT=10
+
+# When a worker's PR fails CI, give Claude the failing job logs to fix it,
+# at most CI_FIX_ATTEMPTS times in a row
+# WATCH_CI=false
+# CI_FIX_ATTEMPTS=3
+
+# Rebase worker PRs onto their base branch whenever it moves, and force-push.
+# Conflicts are given to Claude to resolve, at most AUTO_REBASE_ATTEMPTS times
+# in a row before asking for a manual rebase on the PR
+# AUTO_REBASE=false
+# AUTO_REBASE_ATTEMPTS=2
+
+# What to do when a PR's base branch differs from the branch its worktree was
+# created from: "warn" (log it) or "rebase" (re-anchor onto the new base and force-push)
+# BASE_MISMATCH="warn"
+
+# Overlapping comments from different reviewers: "prompt" (flag the conflict to
+# Claude, which reconciles or asks) or "pause" (skip them and ask reviewers to align)
+# CONFLICT_ACTION="prompt"
+
+# Which phases run claude with --verbose (full message stream in the logs):
+# "all", "implement", "review" (incl. single-PR mode and CI fixes) or "none"
+# CLAUDE_VERBOSE="all"
+
+# Tools Claude may use without asking (claude --allowedTools), e.g.
+# "Read Edit Write Bash(git:*) Bash(gh:*) Bash(./scripts/pr-reply:*)"
+# CLAUDE_ALLOWED_TOOLS=""
+
+# claude --permission-mode: "default", "acceptEdits", "plan" or
+# "bypassPermissions". Runs are non-interactive, so nothing can be approved
+# by hand; "acceptEdits" plus CLAUDE_ALLOWED_TOOLS is a safe choice, and
+# "bypassPermissions" should only be used in Docker mode
+# CLAUDE_PERMISSION_MODE=""
+
+# Extra flags for every claude run, split like a shell command line, e.g.
+# '--max-turns 40 --add-dir "../shared docs"'
+# CLAUDE_EXTRA_ARGS=""
+
+# MCP server config (JSON) passed to every claude run as --mcp-config, relative
+# to the project root. In Docker mode it is mounted into the containers
+# CLAUDE_MCP_CONFIG=".mcp-autopr.json"
+
+# Text added to Claude's system prompt in every run (--append-system-prompt).
+# "@path" reads it from a file, relative to the project root
+# CLAUDE_APPEND_SYSTEM_PROMPT="@.pr-watch/system-prompt.md"
+
+# Only start on a labeled issue once a trusted user comments this (e.g. "/auto-pr go").
+# Trusted: TRIGGER_USERS (comma-separated logins), or if empty, the repo's
+# owners, members and collaborators
+# TRIGGER_COMMENT=""
+# TRIGGER_USERS=""
+
+# Comments and reviews by this login are never treated as new feedback, so the
+# watcher does not react to its own replies. Empty = the user gh is logged in
+# as; "none" = ignore nobody (e.g. if you review your own PRs under that login)
+# BOT_LOGIN=""
+
+# Stop after this many review rounds handled by Claude on one PR, comment that
+# the PR needs a human and mark the issue needs_human (0 = no limit)
+# MAX_REVIEW_ROUNDS=0
+
+# Let trusted users drive workers from their PR's conversation with
+# "/auto-pr rebase", "/auto-pr fix-ci", "/auto-pr pause" and "/auto-pr help".
+# Trusted: COMMAND_USERS (comma-separated logins), or if empty, the repo's
+# owners, members and collaborators
+# PR_COMMANDS=false
+# COMMAND_USERS=""
+
+# Hand Claude at most this many inline comments per run; larger rounds are
+# split into batches (oldest first, same-file comments kept together), each
+# committed and pushed separately (0 = no limit)
+# MAX_COMMENTS_PER_ROUND=0
+
+# Skip issues whose body is shorter than this many characters (0 = off); they
+# are picked up once the body is edited to be long enough. The comment, if
+# set, is posted on each skipped issue
+# MIN_ISSUE_BODY_CHARS=0
+# INSUFFICIENT_DETAIL_COMMENT="Thanks! Could you add more detail (expected behavior, acceptance criteria, steps to reproduce)? I'll pick this up once the description is fleshed out."
+
+# GitHub Enterprise: the host gh talks to (passed as GH_HOST, also to Docker
+# workers) and, for installs served under a subpath or behind a proxy, the
+# path prefix put in front of every REST API call
+# GITHUB_HOST=""
+# GITHUB_API_PREFIX=""
+
+# Console output of watch: "debug" (adds polling chatter such as "Scanning..."
+# and "Sleeping..."), "info", "warn" or "error". --verbose / --quiet override it.
+# Worker log files always get everything
+# LOG_LEVEL="info"
+
+# Scale the number of concurrent workers with the host's load (Linux only;
+# elsewhere MAX_CONCURRENT is used as is): the full MAX_CONCURRENT up to a
+# load average of 0.5 per CPU, MIN_CONCURRENT from 1.0 per CPU or when less
+# than 10% of memory is available. Running workers are never stopped; new
+# issues wait until the budget allows
+# ADAPTIVE_CONCURRENCY=false
+# MIN_CONCURRENT=1
+
+# Keep long review histories cheap: every COMPACT_AFTER_ROUNDS review rounds,
+# or once a review run used COMPACT_INPUT_TOKENS input tokens (cache reads
+# included), the worker asks Claude for a summary of its session and starts
+# the next round in a fresh session seeded with it (0 = off)
+# COMPACT_AFTER_ROUNDS=0
+# COMPACT_INPUT_TOKENS=0
+
+# User worker containers run as. "host" (recommended) uses your UID:GID, so
+# files Claude creates in the repo belong to you and the mounted ~/.claude
+# stays writable; "UID[:GID]" picks another one. Empty = root (files in the
+# repo end up root-owned). Non-root containers get HOME=/tmp
+# DOCKER_USER="host"
+
+# Give worker containers SSH access for private submodules and SSH remotes:
+# ~/.ssh is mounted read-only, the SSH agent (SSH_AUTH_SOCK) is forwarded and
+# github.com's host keys are trusted. Works best with DOCKER_USER="host"
+# DOCKER_MOUNT_SSH=false
+
+# Pull DOCKER_IMAGE (e.g. "ghcr.io/org/worker:tag") when watch starts instead
+# of building it from a Dockerfile, so every machine runs the same image. A
+# failed pull is an error unless an older local copy exists
+# DOCKER_IMAGE_PULL=false
+
+# Network worker containers are attached to (docker run --network), e.g. a
+# network you created with restricted egress. "none" cuts them off entirely,
+# which breaks gh and claude inside the container. Empty = Docker's default
+# bridge with full outbound access
+# DOCKER_NETWORK=""
+
+# Files Claude changes while handling review comments that no inline comment
+# refers to: "revert" (revert them and push), "comment" (also say so on the PR)
+# or "off" (trust the prompt)
+# EDIT_SCOPE="revert"
+
+# Formatter run on the files Claude changed after each run (file names are
+# appended), in the worker's container in Docker mode. Its changes are
+# committed and pushed; a failing formatter is only logged
+# FORMAT_COMMAND="gofmt -w"
+
+# Comment on the issue when a worker starts implementing it and when its PR
+# is opened
+# ISSUE_PROGRESS_COMMENTS=true
+
+# Shell command run on every issue status change, with AUTOPR_EVENT
+# (worker_started, pr_opened, worker_done, worker_failed, needs_human, ...),
+# AUTOPR_ISSUE, AUTOPR_PR, AUTOPR_REPO, AUTOPR_BRANCH, AUTOPR_STATUS and
+# AUTOPR_PREV_STATUS in its environment. Runs detached, killed after 30s
+# ON_EVENT_COMMAND="./notify.sh"
+
+# How long Ctrl-C waits for workers to stop before abandoning them (their
+# containers are removed and their state is kept for the next start).
+# 0 waits forever
+# SHUTDOWN_TIMEOUT=30
+
+# Start at most this many new issues per scan, to work through a backlog of
+# labeled issues gradually; the rest wait for later scans. 0 = no limit
+# MAX_ISSUES_PER_SCAN=0
+
+# Only start new issues within these hours (running workers carry on), e.g.
+# "Mon-Fri 09:00-18:00" or "Mon-Thu 09:00-12:00 13:00-17:00, Fri 09:00-12:00".
+# TIMEZONE is an IANA name such as "Europe/Berlin" (empty = local time). With
+# WORK_HOURS_PAUSE_REVIEWS, workers also leave new review feedback, CI
+# failures and PR commands until working hours
+# WORK_HOURS=""
+# TIMEZONE=""
+# WORK_HOURS_PAUSE_REVIEWS=false
+
+# Wall-clock limits for a worker (seconds, or durations like 2h): the
+# implementation phase up to an open PR, and the review phase after it.
+# A worker over its limit is cancelled, its container stopped and the issue
+# marked failed ("timeout"). 0 = no limit
+# WORKER_TIMEOUT=0
+# REVIEW_TIMEOUT=0
+
+# Issue bodies longer than this many bytes are truncated in the implement
+# prompt, with a "[truncated, N bytes omitted]" marker. Review data over it
+# loses repeated diff hunks, then all diff hunks, and only then has its
+# comment bodies truncated. 0 = no limit
+# MAX_PROMPT_BODY_BYTES=65536
+
+# Have Claude open PRs as drafts, so reviewers are not notified before CI has
+# run; the worker marks the PR ready for review once its checks pass
+# PR_DRAFT=false
+
+# Request reviews on every PR a worker opens: comma-separated logins and
+# org/team slugs. The PR's author is skipped
+# PR_REVIEWERS=""
+
+# Label worker PRs: PR_LABELS (comma-separated) as soon as the PR is found,
+# PR_DONE_LABEL once it is merged or closed and the worker is done
+# PR_LABELS=""
+# PR_DONE_LABEL=""
+
+# Close the issue when its PR merges, even if the PR body does not link it
+# CLOSE_ISSUE_ON_MERGE=true
+
+# Rotate logs/issue-N.log once it reaches LOG_MAX_SIZE_MB (0 = never),
+# keeping LOG_MAX_BACKUPS older files (issue-N.log.1 is the newest)
+# LOG_MAX_SIZE_MB=0
+# LOG_MAX_BACKUPS=3
+
+# Move done/failed issue state to .pr-watch-state/issues/archive/ once it has
+# not changed for this long (seconds or a duration like 168h; 0 = never)
+# ARCHIVE_AFTER=604800
 `
 
+// explicitPath is the config file chosen with --config or AUTOPR_CONFIG
+// ("" = <projectRoot>/.pr-watch.conf).
+var explicitPath string
+
+// SetPath makes Load read path instead of <projectRoot>/.pr-watch.conf. The
+// file must exist: a mistyped path should not silently mean defaults.
+func SetPath(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	explicitPath = abs
+	return nil
+}
+
+// Path returns the config file Load reads for projectRoot.
+func Path(projectRoot string) string {
+	if explicitPath != "" {
+		return explicitPath
+	}
+	return filepath.Join(projectRoot, ".pr-watch.conf")
+}
+
 // GenerateDefault creates a .pr-watch.conf with commented-out defaults
 // if the file does not already exist. Returns true if a file was created.
 func GenerateDefault(projectRoot string) bool {
-	path := filepath.Join(projectRoot, ".pr-watch.conf")
+	path := Path(projectRoot)
 	if _, err := os.Stat(path); err == nil {
 		return false // already exists
 	}
@@ -73,65 +480,371 @@ func GenerateDefault(projectRoot string) bool {
 	return true
 }
 
-// Load reads .pr-watch.conf from projectRoot and returns the config.
-// Missing file is not an error; defaults are used.
+// Load reads .pr-watch.conf from projectRoot (or the file given to SetPath)
+// and returns the config. Missing file is not an error; defaults are used. Invalid values are
+// reported on stderr and ignored.
 func Load(projectRoot string) Config {
+	cfg, warnings := LoadWithWarnings(projectRoot)
+	for _, w := range warnings {
+		// Unknown keys are ignored so configs stay compatible across versions.
+		if !w.Unknown {
+			fmt.Fprintf(os.Stderr, "[auto-pr] Warning: %s: %s (ignored)\n", filepath.Base(Path(projectRoot)), w.Msg)
+		}
+	}
+	return cfg
+}
+
+// Warning is a problem with one line of .pr-watch.conf.
+type Warning struct {
+	Line    int
+	Key     string
+	Msg     string
+	Unknown bool // the key is not recognized
+}
+
+// LoadWithWarnings is Load, returning the problems found instead of printing
+// them: unknown keys, lines without "=" and invalid values (which leave the
+// default in place).
+func LoadWithWarnings(projectRoot string) (Config, []Warning) {
+	cfg, _, warnings := LoadWithSources(projectRoot)
+	return cfg, warnings
+}
+
+// Source says where an effective config value came from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = ".pr-watch.conf"
+	SourceSet     Source = "--set"
+	SourceFlag    Source = "flag"
+)
+
+// Sources maps config keys to the source of their value. Keys that are
+// missing have their default.
+type Sources map[string]Source
+
+// Of returns the source of key's value.
+func (s Sources) Of(key string) Source {
+	if src, ok := s[key]; ok {
+		return src
+	}
+	return SourceDefault
+}
+
+// LoadWithSources is LoadWithWarnings, also recording which keys were set
+// by the file.
+func LoadWithSources(projectRoot string) (Config, Sources, []Warning) {
 	cfg := DefaultConfig()
+	sources := Sources{}
 
-	f, err := os.Open(filepath.Join(projectRoot, ".pr-watch.conf"))
+	f, err := os.Open(Path(projectRoot))
 	if err != nil {
-		return cfg
+		return cfg, sources, nil
 	}
 	defer f.Close()
 
+	var warnings []Warning
 	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
+	for n := 1; scanner.Scan(); n++ {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 		idx := strings.Index(line, "=")
 		if idx < 0 {
+			warnings = append(warnings, Warning{Line: n, Msg: fmt.Sprintf("expected KEY=VALUE, got %q", line)})
 			continue
 		}
 		key := strings.TrimSpace(line[:idx])
-		val := strings.TrimSpace(line[idx+1:])
-		// Strip inline comments and surrounding quotes
-		if len(val) > 0 && (val[0] == '"' || val[0] == '\'') {
-			q := val[0]
-			if end := strings.IndexByte(val[1:], q); end >= 0 {
-				val = val[1 : end+1]
-			} else {
-				val = strings.Trim(val, `"'`)
+		val := unquote(strings.TrimSpace(line[idx+1:]))
+		if err := cfg.Set(key, val); err != nil {
+			w := Warning{Line: n, Key: key, Msg: err.Error(), Unknown: errors.Is(err, ErrUnknownKey)}
+			if w.Unknown {
+				w.Msg = fmt.Sprintf("unknown key %s", key)
 			}
-		} else if i := strings.Index(val, "#"); i > 0 {
-			val = strings.TrimSpace(val[:i])
+			warnings = append(warnings, w)
+			continue
 		}
+		sources[key] = SourceFile
+	}
+	return cfg, sources, warnings
+}
 
-		switch key {
-		case "MAX_CONCURRENT":
-			if n, err := strconv.Atoi(val); err == nil && n > 0 {
-				cfg.MaxConcurrent = n
-			}
-		case "INTERVAL":
-			if n, err := strconv.Atoi(val); err == nil && n > 0 {
-				cfg.Interval = n
-			}
-		case "ISSUE_LABELS":
-			cfg.IssueLabels = val
-		case "WORKTREE_DIR":
-			cfg.WorktreeDir = val
-		case "BASE_BRANCH":
-			cfg.BaseBranch = val
-		case "DOCKER":
-			cfg.DockerEnabled = val == "true" || val == "1" || val == "yes"
-		case "DOCKER_IMAGE":
-			if val != "" {
-				cfg.DockerImage = val
+// unquote strips surrounding quotes, or an inline comment from an unquoted value.
+func unquote(val string) string {
+	if len(val) > 0 && (val[0] == '"' || val[0] == '\'') {
+		q := val[0]
+		if end := strings.IndexByte(val[1:], q); end >= 0 {
+			return val[1 : end+1]
+		}
+		return strings.Trim(val, `"'`)
+	}
+	if i := strings.Index(val, "#"); i > 0 {
+		return strings.TrimSpace(val[:i])
+	}
+	return val
+}
+
+// ErrUnknownKey is returned by Set for keys it does not recognize.
+var ErrUnknownKey = errors.New("unknown config key")
+
+// ApplyOverride applies a "KEY=VALUE" override (watch --set). Unlike the
+// config file, unknown keys and invalid values are errors.
+func (c *Config) ApplyOverride(kv string) error {
+	idx := strings.Index(kv, "=")
+	if idx <= 0 {
+		return fmt.Errorf("invalid override %q (expected KEY=VALUE)", kv)
+	}
+	key := strings.TrimSpace(kv[:idx])
+	if err := c.Set(key, unquote(strings.TrimSpace(kv[idx+1:]))); err != nil {
+		if errors.Is(err, ErrUnknownKey) {
+			return fmt.Errorf("%w %q", ErrUnknownKey, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Set applies one config key. Invalid values leave the field unchanged and
+// return an error; unrecognized keys return ErrUnknownKey.
+func (c *Config) Set(key, val string) error {
+	switch key {
+	case "MAX_CONCURRENT":
+		return setPositive(&c.MaxConcurrent, key, val)
+	case "INTERVAL":
+		return setPositive(&c.Interval, key, val)
+	case "ISSUE_LABELS":
+		c.IssueLabels = val
+	case "ISSUE_EXCLUDE_LABELS":
+		c.IssueExcludeLabels = val
+	case "PRIORITY_LABELS":
+		c.PriorityLabels = val
+	case "WORKTREE_DIR":
+		c.WorktreeDir = val
+	case "WORKTREE_SHALLOW":
+		return setBool(&c.WorktreeShallow, key, val)
+	case "BASE_BRANCH":
+		c.BaseBranch = val
+	case "DOCKER":
+		return setBool(&c.DockerEnabled, key, val)
+	case "DOCKER_IMAGE":
+		if val != "" {
+			c.DockerImage = val
+		}
+	case "DOCKER_FILE":
+		c.DockerFile = val
+	case "COMMIT_TRAILER":
+		if val != "" && !ValidTrailer(val) {
+			return fmt.Errorf("invalid COMMIT_TRAILER %q (expected \"Token: value\")", val)
+		}
+		c.CommitTrailer = val
+	case "DEDUP_COMMENTS":
+		return setBool(&c.DedupComments, key, val)
+	case "UPLOAD_LOG_ON_FAILURE":
+		return setBool(&c.UploadLogOnFailure, key, val)
+	case "WEBHOOK_SECRET":
+		c.WebhookSecret = val
+	case "SHUTDOWN_TIMEOUT":
+		return setSeconds(&c.ShutdownTimeout, key, val, true)
+	case "MAX_ISSUES_PER_SCAN":
+		return setNonNegative(&c.MaxIssuesPerScan, key, val)
+	case "WORKER_TIMEOUT":
+		return setSeconds(&c.WorkerTimeout, key, val, true)
+	case "REVIEW_TIMEOUT":
+		return setSeconds(&c.ReviewTimeout, key, val, true)
+	case "MAX_PROMPT_BODY_BYTES":
+		return setNonNegative(&c.MaxPromptBodyBytes, key, val)
+	case "PR_DRAFT":
+		return setBool(&c.PRDraft, key, val)
+	case "PR_REVIEWERS":
+		c.PRReviewers = val
+	case "PR_LABELS":
+		c.PRLabels = val
+	case "PR_DONE_LABEL":
+		c.PRDoneLabel = val
+	case "CLOSE_ISSUE_ON_MERGE":
+		return setBool(&c.CloseIssueOnMerge, key, val)
+	case "LOG_MAX_SIZE_MB":
+		return setNonNegative(&c.LogMaxSizeMB, key, val)
+	case "LOG_MAX_BACKUPS":
+		return setNonNegative(&c.LogMaxBackups, key, val)
+	case "ARCHIVE_AFTER":
+		return setSeconds(&c.ArchiveAfter, key, val, true)
+	case "WORK_HOURS":
+		if val != "" {
+			if _, err := schedule.Parse(val, ""); err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
 			}
-		case "DOCKER_FILE":
-			cfg.DockerFile = val
 		}
+		c.WorkHours = val
+	case "TIMEZONE":
+		if _, err := time.LoadLocation(val); err != nil {
+			return fmt.Errorf("invalid %s %q: unknown time zone", key, val)
+		}
+		c.Timezone = val
+	case "WORK_HOURS_PAUSE_REVIEWS":
+		return setBool(&c.WorkHoursPauseReviews, key, val)
+	case "DOCKER_START_TIMEOUT":
+		return setSeconds(&c.DockerStartTimeout, key, val, true)
+	case "DOCKER_FALLBACK_LOCAL":
+		return setBool(&c.DockerFallbackLocal, key, val)
+	case "DOCKER_USER":
+		c.DockerUser = val
+	case "DOCKER_MOUNT_SSH":
+		return setBool(&c.DockerMountSSH, key, val)
+	case "DOCKER_IMAGE_PULL":
+		return setBool(&c.DockerImagePull, key, val)
+	case "DOCKER_NETWORK":
+		c.DockerNetwork = val
+	case "AUTO_APPLY_SUGGESTIONS":
+		return setBool(&c.AutoApplySuggestions, key, val)
+	case "AUTO_MERGE":
+		return setEnum(&c.AutoMerge, key, val, "", "squash", "merge", "rebase")
+	case "SPEC_URL_ALLOWLIST":
+		c.SpecURLAllowlist = val
+	case "SPEC_MAX_BYTES":
+		return setPositive(&c.SpecMaxBytes, key, val)
+	case "SPEC_FETCH_TIMEOUT":
+		return setSeconds(&c.SpecFetchTimeout, key, val, false)
+	case "WATCH_CI":
+		return setBool(&c.WatchCI, key, val)
+	case "CI_FIX_ATTEMPTS":
+		return setNonNegative(&c.CIFixAttempts, key, val)
+	case "AUTO_REBASE":
+		return setBool(&c.AutoRebase, key, val)
+	case "AUTO_REBASE_ATTEMPTS":
+		return setNonNegative(&c.AutoRebaseAttempts, key, val)
+	case "BASE_MISMATCH":
+		return setEnum(&c.BaseMismatch, key, val, "warn", "rebase")
+	case "ON_EVENT_COMMAND":
+		c.OnEventCommand = val
+	case "ISSUE_PROGRESS_COMMENTS":
+		return setBool(&c.IssueProgressComments, key, val)
+	case "FORMAT_COMMAND":
+		c.FormatCommand = val
+	case "EDIT_SCOPE":
+		return setEnum(&c.EditScope, key, val, "off", "revert", "comment")
+	case "CONFLICT_ACTION":
+		return setEnum(&c.ConflictAction, key, val, "prompt", "pause")
+	case "CLAUDE_ALLOWED_TOOLS":
+		c.ClaudeAllowedTools = val
+	case "CLAUDE_PERMISSION_MODE":
+		return setEnum(&c.ClaudePermissionMode, key, val, "", "default", "acceptEdits", "plan", "bypassPermissions")
+	case "CLAUDE_EXTRA_ARGS":
+		if _, err := SplitWords(val); err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.ClaudeExtraArgs = val
+	case "CLAUDE_APPEND_SYSTEM_PROMPT":
+		c.ClaudeAppendSystemPrompt = val
+	case "CLAUDE_MCP_CONFIG":
+		c.ClaudeMCPConfig = val
+	case "CLAUDE_VERBOSE":
+		return setEnum(&c.ClaudeVerbose, key, val, "all", "implement", "review", "none")
+	case "TRIGGER_COMMENT":
+		c.TriggerComment = val
+	case "TRIGGER_USERS":
+		c.TriggerUsers = val
+	case "BOT_LOGIN":
+		c.BotLogin = val
+	case "MAX_REVIEW_ROUNDS":
+		return setNonNegative(&c.MaxReviewRounds, key, val)
+	case "PR_COMMANDS":
+		return setBool(&c.PRCommands, key, val)
+	case "COMMAND_USERS":
+		c.CommandUsers = val
+	case "MAX_COMMENTS_PER_ROUND":
+		return setNonNegative(&c.MaxCommentsPerRound, key, val)
+	case "MIN_ISSUE_BODY_CHARS":
+		return setNonNegative(&c.MinIssueBodyChars, key, val)
+	case "INSUFFICIENT_DETAIL_COMMENT":
+		c.InsufficientDetailComment = val
+	case "GITHUB_HOST":
+		c.GitHubHost = val
+	case "GITHUB_API_PREFIX":
+		c.GitHubAPIPrefix = val
+	case "ADAPTIVE_CONCURRENCY":
+		return setBool(&c.AdaptiveConcurrency, key, val)
+	case "MIN_CONCURRENT":
+		return setPositive(&c.MinConcurrent, key, val)
+	case "COMPACT_AFTER_ROUNDS":
+		return setNonNegative(&c.CompactAfterRounds, key, val)
+	case "COMPACT_INPUT_TOKENS":
+		return setNonNegative(&c.CompactInputTokens, key, val)
+	case "LOG_LEVEL":
+		return setEnum(&c.LogLevel, key, strings.ToLower(val), "debug", "info", "warn", "error")
+	default:
+		return ErrUnknownKey
 	}
-	return cfg
+	return nil
+}
+
+// setBool accepts true/1/yes and false/0/no (or empty, meaning false).
+func setBool(dst *bool, key, val string) error {
+	switch val {
+	case "true", "1", "yes":
+		*dst = true
+	case "false", "0", "no", "":
+		*dst = false
+	default:
+		return fmt.Errorf("%s must be true or false, got %q", key, val)
+	}
+	return nil
+}
+
+func setPositive(dst *int, key, val string) error {
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("%s must be a positive integer, got %q", key, val)
+	}
+	*dst = n
+	return nil
+}
+
+func setNonNegative(dst *int, key, val string) error {
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%s must be a non-negative integer, got %q", key, val)
+	}
+	*dst = n
+	return nil
+}
+
+// setSeconds parses val with parseSeconds; zero is only accepted if allowZero.
+func setSeconds(dst *int, key, val string, allowZero bool) error {
+	n, ok := parseSeconds(val)
+	if !ok || (n == 0 && !allowZero) {
+		return fmt.Errorf("%s must be a number of seconds or a duration like 2m, got %q", key, val)
+	}
+	*dst = n
+	return nil
+}
+
+func setEnum(dst *string, key, val string, allowed ...string) error {
+	for _, a := range allowed {
+		if val == a {
+			*dst = val
+			return nil
+		}
+	}
+	var names []string
+	for _, a := range allowed {
+		if a != "" {
+			names = append(names, a)
+		}
+	}
+	return fmt.Errorf("invalid %s %q (expected %s)", key, val, strings.Join(names, ", "))
+}
+
+// parseSeconds accepts either a whole number of seconds ("90") or a Go
+// duration ("2m", "1h30m") and returns the value in seconds.
+func parseSeconds(val string) (int, bool) {
+	if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+		return n, true
+	}
+	if d, err := time.ParseDuration(val); err == nil && d >= 0 {
+		return int(d / time.Second), true
+	}
+	return 0, false
 }
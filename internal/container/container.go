@@ -3,6 +3,7 @@ package container
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +12,8 @@ import (
 	"strings"
 
 	"auto-pr/internal/ghcli"
+	"auto-pr/internal/logging"
+	"auto-pr/internal/redact"
 )
 
 // defaultDockerfile is embedded into the binary as a fallback when no external
@@ -43,10 +46,13 @@ RUN curl -fsSL https://go.dev/dl/go1.22.5.linux-amd64.tar.gz \
     | tar -C /usr/local -xzf -
 ENV PATH="/usr/local/go/bin:/root/go/bin:${PATH}"
 
-# Rust (via rustup, minimal profile)
+# Rust (via rustup, minimal profile), outside /root so non-root workers
+# (DOCKER_USER) can use it
+ENV RUSTUP_HOME=/usr/local/rustup CARGO_HOME=/usr/local/cargo
 RUN curl --proto '=https' --tlsv1.2 -sSf https://sh.rustup.rs \
-    | sh -s -- -y --default-toolchain stable --profile minimal
-ENV PATH="/root/.cargo/bin:${PATH}"
+    | sh -s -- -y --no-modify-path --default-toolchain stable --profile minimal \
+    && chmod -R a+w /usr/local/rustup /usr/local/cargo
+ENV PATH="/usr/local/cargo/bin:${PATH}"
 
 # gh CLI
 RUN curl -fsSL https://cli.github.com/packages/githubcli-archive-keyring.gpg \
@@ -80,8 +86,16 @@ type Manager struct {
 	ImageName      string
 	ProjectRoot    string
 	DockerfilePath string // optional: explicit Dockerfile path from config
+	User           string // "UID[:GID]" containers run as ("" = the image's user, normally root)
+	MountSSH       bool   // mount ~/.ssh read-only and forward the SSH agent
+	Pull           bool   // pull ImageName from its registry instead of building it
+	Network        string // docker --network for containers ("" = Docker's default bridge)
+	MCPConfig      string // host path of an MCP config mounted read-only at MCPConfigPath
 }
 
+// MCPConfigPath is where Manager.MCPConfig is mounted in containers.
+const MCPConfigPath = "/etc/auto-pr/mcp.json"
+
 // NewManager creates a new container manager.
 func NewManager(imageName, projectRoot, dockerfilePath string) *Manager {
 	return &Manager{
@@ -96,7 +110,8 @@ func NewManager(imageName, projectRoot, dockerfilePath string) *Manager {
 //  2. {projectRoot}/Dockerfile.autopr
 //  3. Embedded default written to a temp file
 //
-// Returns the path and whether it's a temp file that the caller should remove.
+// Returns the path and whether it's a temp file whose directory the caller
+// should remove.
 func (m *Manager) resolveDockerfile() (path string, isTempFile bool, err error) {
 	// 1. Explicit config path
 	if m.DockerfilePath != "" {
@@ -112,49 +127,101 @@ func (m *Manager) resolveDockerfile() (path string, isTempFile bool, err error)
 		return autoprPath, false, nil
 	}
 
-	// 3. Embedded default → temp file
-	tmp, err := os.CreateTemp("", "auto-pr-dockerfile-*")
+	// 3. Embedded default → temp file, alone in its own directory so the
+	//    build context is empty rather than all of the temp dir
+	dir, err := os.MkdirTemp("", "auto-pr-docker-*")
 	if err != nil {
 		return "", false, fmt.Errorf("failed to create temp Dockerfile: %w", err)
 	}
-	if _, err := tmp.WriteString(defaultDockerfile); err != nil {
-		tmp.Close()
-		os.Remove(tmp.Name())
+	path = filepath.Join(dir, "Dockerfile")
+	if err := os.WriteFile(path, []byte(defaultDockerfile), 0644); err != nil {
+		os.RemoveAll(dir)
 		return "", false, fmt.Errorf("failed to write temp Dockerfile: %w", err)
 	}
-	tmp.Close()
-	return tmp.Name(), true, nil
+	return path, true, nil
 }
 
-// EnsureImage checks if the Docker image exists; if not, builds it using
-// the resolved Dockerfile (config path → Dockerfile.autopr → embedded default).
+// EnsureImage makes sure the Docker image is available. With Pull it pulls
+// the image (keeping an existing local copy if the pull fails); otherwise it
+// builds the image if it does not exist yet (see Build).
 func (m *Manager) EnsureImage(ctx context.Context) error {
-	// Check if image already exists
-	cmd := exec.CommandContext(ctx, dockerPath, "image", "inspect", m.ImageName)
-	if err := cmd.Run(); err == nil {
-		return nil // image exists
+	// Another worker or auto-pr process may be building the same image; the
+	// existence check below runs after it has finished.
+	unlock, err := lockImage(ctx, m.ImageName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	exists := exec.CommandContext(ctx, dockerPath, "image", "inspect", m.ImageName).Run() == nil
+	if m.Pull {
+		err := m.PullImage(ctx)
+		if err != nil && exists {
+			fmt.Fprintf(os.Stderr, "[docker] Warning: %v; using the local copy of %s\n", err, m.ImageName)
+			return nil
+		}
+		return err
 	}
+	if exists {
+		return nil
+	}
+	return m.Build(ctx, false)
+}
 
+// PullImage pulls the image from its registry.
+func (m *Manager) PullImage(ctx context.Context) error {
+	fmt.Fprintf(logging.Stdout(), "[docker] Pulling image %s...\n", m.ImageName)
+	cmd := exec.CommandContext(ctx, dockerPath, "pull", m.ImageName)
+	cmd.Stdout = logging.Stdout()
+	cmd.Stderr = logging.Stderr()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker pull %s failed (DOCKER_IMAGE_PULL is set; check the image name and 'docker login'): %w", m.ImageName, err)
+	}
+	return nil
+}
+
+// Build builds the image from the resolved Dockerfile (config path →
+// Dockerfile.autopr → embedded default), replacing any existing image of
+// that name. noCache passes --no-cache.
+func (m *Manager) Build(ctx context.Context, noCache bool) error {
 	dockerfilePath, isTmp, err := m.resolveDockerfile()
 	if err != nil {
 		return err
 	}
 	if isTmp {
-		defer os.Remove(dockerfilePath)
+		defer os.RemoveAll(filepath.Dir(dockerfilePath))
 	}
 
-	fmt.Printf("[docker] Building image %s from %s...\n", m.ImageName, dockerfilePath)
-	cmd = exec.CommandContext(ctx, dockerPath, "build", "-t", m.ImageName, "-f", dockerfilePath, ".")
-	cmd.Dir = filepath.Dir(dockerfilePath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// The build context (the Dockerfile's directory) is passed as an argument
+	// rather than via the working directory, so paths with spaces or other
+	// special characters reach docker intact.
+	fmt.Fprintf(logging.Stdout(), "[docker] Building image %s from %s...\n", m.ImageName, dockerfilePath)
+	args := []string{"build", "-t", m.ImageName, "-f", dockerfilePath}
+	if noCache {
+		args = append(args, "--no-cache")
+	}
+	cmd := exec.CommandContext(ctx, dockerPath, append(args, filepath.Dir(dockerfilePath))...)
+	cmd.Stdout = logging.Stdout()
+	cmd.Stderr = logging.Stderr()
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("docker build failed: %w", err)
 	}
-	fmt.Printf("[docker] Image %s built successfully.\n", m.ImageName)
+	fmt.Fprintf(logging.Stdout(), "[docker] Image %s built successfully.\n", m.ImageName)
 	return nil
 }
 
+// RemoveImage deletes the image. It reports whether there was one.
+func (m *Manager) RemoveImage(ctx context.Context) (bool, error) {
+	if exec.CommandContext(ctx, dockerPath, "image", "inspect", m.ImageName).Run() != nil {
+		return false, nil
+	}
+	out, err := exec.CommandContext(ctx, dockerPath, "rmi", m.ImageName).CombinedOutput()
+	if err != nil {
+		return true, fmt.Errorf("docker rmi failed: %w\n%s", err, out)
+	}
+	return true, nil
+}
+
 // Start launches a long-running container (sleep infinity) with the project root bind-mounted.
 // Returns the container ID.
 func (m *Manager) Start(ctx context.Context, name string, env map[string]string) (string, error) {
@@ -162,15 +229,41 @@ func (m *Manager) Start(ctx context.Context, name string, env map[string]string)
 	stopCmd := exec.CommandContext(ctx, dockerPath, "rm", "-f", name)
 	stopCmd.Run() // ignore error — container may not exist
 
+	// Each mount is a single argument, so host paths with spaces need no
+	// quoting.
 	args := []string{
 		"run", "-d",
 		"--name", name,
 		"-v", m.ProjectRoot + ":/workspace",
 	}
+	args = append(args, m.labelArgs()...)
+	if m.Network != "" {
+		args = append(args, "--network", m.Network)
+	}
+
+	// A non-root user has no home in the image; /tmp is writable by
+	// anyone, and claude keeps state next to ~/.claude.
+	home := "/root"
+	if m.User != "" {
+		home = "/tmp"
+		args = append(args, "--user", m.User, "-e", "HOME="+home)
+	}
 
 	// Mount host ~/.claude/ into container so subscription login session is inherited
 	if claudeDir := claudeConfigDir(); claudeDir != "" {
-		args = append(args, "-v", claudeDir+":/root/.claude")
+		args = append(args, "-v", claudeDir+":"+home+"/.claude")
+	}
+
+	if m.MountSSH {
+		sshArgs, err := sshMounts(home)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, sshArgs...)
+	}
+
+	if m.MCPConfig != "" {
+		args = append(args, "-v", m.MCPConfig+":"+MCPConfigPath+":ro")
 	}
 
 	for k, v := range env {
@@ -184,7 +277,7 @@ func (m *Manager) Start(ctx context.Context, name string, env map[string]string)
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("docker run failed: %w\n%s", err, stderr.String())
+		return "", fmt.Errorf("docker run failed: %w\n%s", err, redact.String(stderr.String()))
 	}
 
 	containerID := strings.TrimSpace(stdout.String())
@@ -192,7 +285,8 @@ func (m *Manager) Start(ctx context.Context, name string, env map[string]string)
 	return containerID, nil
 }
 
-// Exec runs a command inside a running container, streaming output to logWriter.
+// Exec runs a command inside a running container, streaming its output,
+// with secrets masked, to stdout and logWriter.
 func (m *Manager) Exec(ctx context.Context, containerID, workDir string, cmdArgs []string, logWriter io.Writer) error {
 	args := []string{"exec"}
 	if workDir != "" {
@@ -203,15 +297,20 @@ func (m *Manager) Exec(ctx context.Context, containerID, workDir string, cmdArgs
 
 	cmd := exec.CommandContext(ctx, dockerPath, args...)
 
+	var stdout, stderr *redact.Writer
 	if logWriter != nil {
-		cmd.Stdout = io.MultiWriter(os.Stdout, logWriter)
-		cmd.Stderr = io.MultiWriter(os.Stderr, logWriter)
+		stdout = redact.NewWriter(io.MultiWriter(logging.Stdout(), logWriter))
+		stderr = redact.NewWriter(io.MultiWriter(logging.Stderr(), logWriter))
 	} else {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		stdout = redact.NewWriter(logging.Stdout())
+		stderr = redact.NewWriter(logging.Stderr())
 	}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
 
-	return cmd.Run()
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	return err
 }
 
 // Stop stops and removes a container.
@@ -226,6 +325,14 @@ func (m *Manager) Stop(ctx context.Context, containerID string) error {
 	return nil
 }
 
+// Kill removes a container without giving it time to shut down.
+func (m *Manager) Kill(ctx context.Context, containerID string) error {
+	if err := exec.CommandContext(ctx, dockerPath, "rm", "-f", containerID).Run(); err != nil {
+		return fmt.Errorf("docker rm failed: %w", err)
+	}
+	return nil
+}
+
 // IsRunning checks if a container is currently running.
 func (m *Manager) IsRunning(ctx context.Context, containerID string) bool {
 	cmd := exec.CommandContext(ctx, dockerPath, "inspect", "-f", "{{.State.Running}}", containerID)
@@ -250,6 +357,45 @@ func claudeConfigDir() string {
 	return ""
 }
 
+// ResolveUser turns a DOCKER_USER value into a docker --user argument:
+// "" and "root" mean root (returned as ""), "host" the current user's
+// UID:GID, anything else is passed through.
+func ResolveUser(spec string) (string, error) {
+	switch spec {
+	case "", "root":
+		return "", nil
+	case "host":
+		uid, gid := os.Getuid(), os.Getgid()
+		if uid < 0 {
+			return "", fmt.Errorf("DOCKER_USER=host is not supported on this platform; set UID:GID explicitly")
+		}
+		return fmt.Sprintf("%d:%d", uid, gid), nil
+	}
+	return spec, nil
+}
+
+// ClaudeDirMounted reports whether containers get the host's ~/.claude, and
+// with it Claude sessions that outlive the container.
+func ClaudeDirMounted() bool {
+	return claudeConfigDir() != ""
+}
+
+// ErrNoClaudeAuth means containers would start without any Claude credentials.
+var ErrNoClaudeAuth = errors.New("Claude will not be authenticated in worker containers: set ANTHROPIC_API_KEY, or log in with 'claude' on the host so ~/.claude can be mounted")
+
+// ClaudeAuth reports how Claude authenticates inside worker containers: with
+// ANTHROPIC_API_KEY when it is set, otherwise with the subscription login in
+// the mounted ~/.claude. Returns ErrNoClaudeAuth if neither is available.
+func ClaudeAuth() (string, error) {
+	if os.Getenv("ANTHROPIC_API_KEY") != "" {
+		return "ANTHROPIC_API_KEY", nil
+	}
+	if dir := claudeConfigDir(); dir != "" {
+		return "subscription login (" + dir + " mounted)", nil
+	}
+	return "", ErrNoClaudeAuth
+}
+
 // GetWorkerEnv collects environment variables needed inside the container.
 func GetWorkerEnv() map[string]string {
 	env := map[string]string{}
@@ -259,15 +405,14 @@ func GetWorkerEnv() map[string]string {
 		env["ANTHROPIC_API_KEY"] = key
 	}
 
-	// GitHub token: prefer GH_TOKEN env var, fall back to gh auth token
-	if token := os.Getenv("GH_TOKEN"); token != "" {
-		env["GH_TOKEN"] = token
-	} else if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+	if token := resolveGitHubToken(); token != "" {
 		env["GH_TOKEN"] = token
-	} else {
-		out, err := exec.Command(ghcli.Path(), "auth", "token").Output()
-		if err == nil {
-			env["GH_TOKEN"] = strings.TrimSpace(string(out))
+	}
+	if host := ghcli.Host(); host != "" {
+		env["GH_HOST"] = host
+		// gh reads the token for Enterprise hosts from GH_ENTERPRISE_TOKEN.
+		if host != "github.com" && env["GH_TOKEN"] != "" {
+			env["GH_ENTERPRISE_TOKEN"] = env["GH_TOKEN"]
 		}
 	}
 
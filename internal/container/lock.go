@@ -0,0 +1,38 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// imageLocks serializes EnsureImage per image name within this process.
+var imageLocks sync.Map // image name → *sync.Mutex
+
+// lockImage takes the in-process lock for name and, where the platform
+// supports it, an exclusive lock on a file in the temp directory shared by
+// every auto-pr process, so only one of them builds or pulls name at a time.
+// The returned function releases both.
+func lockImage(ctx context.Context, name string) (func(), error) {
+	mu, _ := imageLocks.LoadOrStore(name, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+
+	safe := strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(name)
+	path := filepath.Join(os.TempDir(), "auto-pr-image-"+safe+".lock")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o666)
+	if err != nil {
+		// Cross-process locking is best effort; in-process still holds.
+		return mu.(*sync.Mutex).Unlock, nil
+	}
+	if err := lockFile(ctx, f); err != nil {
+		f.Close()
+		mu.(*sync.Mutex).Unlock()
+		return nil, err
+	}
+	return func() {
+		f.Close() // releases the file lock
+		mu.(*sync.Mutex).Unlock()
+	}, nil
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package container
+
+import (
+	"context"
+	"os"
+)
+
+// lockFile is a no-op where flock is not available; builds are then only
+// serialized within a process.
+func lockFile(ctx context.Context, f *os.File) error {
+	return nil
+}
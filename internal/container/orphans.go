@@ -0,0 +1,85 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Labels set on every worker container, so a later run can tell its own
+// leftovers from containers of other projects or live processes.
+const (
+	labelProject = "auto-pr.project"
+	labelPID     = "auto-pr.pid"
+)
+
+// workerNameRE matches the container names used by the watch package.
+var workerNameRE = regexp.MustCompile(`^worker-(issue|pr)-\d+$`)
+
+// labelArgs returns the docker run arguments labelling a container as
+// started by this process for this project.
+func (m *Manager) labelArgs() []string {
+	return []string{
+		"--label", labelProject + "=" + m.ProjectRoot,
+		"--label", labelPID + "=" + strconv.Itoa(os.Getpid()),
+	}
+}
+
+// CleanupOrphans removes worker containers left behind by an auto-pr process
+// that is no longer running (e.g. one that was killed), and returns their
+// names. Containers of other projects, and of auto-pr processes that are
+// still alive, are left alone. Unlabelled containers with worker names are
+// leftovers of older versions and are removed too.
+func (m *Manager) CleanupOrphans(ctx context.Context) ([]string, error) {
+	format := fmt.Sprintf("{{.ID}}\t{{.Names}}\t{{.Label %q}}\t{{.Label %q}}", labelProject, labelPID)
+	cmd := exec.CommandContext(ctx, dockerPath, "ps", "-a", "--filter", "name=worker-", "--format", format)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("docker ps failed: %w\n%s", err, stderr.String())
+	}
+
+	var removed []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 || !workerNameRE.MatchString(fields[1]) {
+			continue
+		}
+		id, name, project, pidLabel := fields[0], fields[1], fields[2], fields[3]
+		if project != "" && project != m.ProjectRoot {
+			continue
+		}
+		if pid, err := strconv.Atoi(pidLabel); err == nil && processAlive(pid) {
+			continue
+		}
+		if err := exec.CommandContext(ctx, dockerPath, "rm", "-f", id).Run(); err != nil {
+			return removed, fmt.Errorf("docker rm %s failed: %w", name, err)
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
+
+// processAlive reports whether a process with the given PID exists. On
+// Windows, finding the process is the only check available.
+func processAlive(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,74 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// githubKnownHosts holds github.com's published SSH host keys
+// (https://docs.github.com/en/authentication/keeping-your-account-and-data-secure/githubs-ssh-key-fingerprints).
+const githubKnownHosts = `github.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl
+github.com ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBEmKSENjQEezOmxkZMy7opKgwFB9nkt5YRrYMjNuG5N87uRgg6CLrbo5wAdT/y6v0mKV0U2w0WZ2YB/++Tpockg=
+`
+
+// agentSocket is where the SSH agent socket is mounted in containers.
+const agentSocket = "/ssh-agent"
+
+// sshMounts returns the docker run arguments for DOCKER_MOUNT_SSH: the
+// host's ~/.ssh read-only under home, the SSH agent socket if one is
+// running, and github.com's host keys as the system-wide known_hosts.
+func sshMounts(home string) ([]string, error) {
+	var args []string
+
+	if hostHome, err := os.UserHomeDir(); err == nil {
+		dir := filepath.Join(hostHome, ".ssh")
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			args = append(args, "-v", dir+":"+home+"/.ssh:ro")
+		}
+	}
+
+	// Docker Desktop on macOS cannot mount host sockets; it forwards the
+	// agent at a fixed path instead.
+	if runtime.GOOS == "darwin" {
+		args = append(args, "-v", "/run/host-services/ssh-auth.sock:"+agentSocket, "-e", "SSH_AUTH_SOCK="+agentSocket)
+	} else if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if _, err := os.Stat(sock); err == nil {
+			args = append(args, "-v", sock+":"+agentSocket, "-e", "SSH_AUTH_SOCK="+agentSocket)
+		}
+	}
+
+	knownHosts, err := writeKnownHosts()
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "-v", knownHosts+":/etc/ssh/ssh_known_hosts:ro")
+	return args, nil
+}
+
+// writeKnownHosts writes githubKnownHosts to a file in the temp directory
+// and returns its path. The file is replaced atomically, since workers
+// start containers concurrently.
+func writeKnownHosts() (string, error) {
+	path := filepath.Join(os.TempDir(), "auto-pr-known_hosts")
+	f, err := os.CreateTemp(os.TempDir(), "auto-pr-known_hosts-*")
+	if err != nil {
+		return "", fmt.Errorf("write known_hosts: %w", err)
+	}
+	_, err = f.WriteString(githubKnownHosts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("write known_hosts: %w", err)
+	}
+	return path, nil
+}
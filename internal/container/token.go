@@ -0,0 +1,75 @@
+package container
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"auto-pr/internal/ghcli"
+	"auto-pr/internal/logging"
+	"auto-pr/internal/redact"
+)
+
+// resolveGitHubToken finds the GitHub token handed to workers. Sources, in
+// order:
+//  1. the GH_TOKEN or GITHUB_TOKEN environment variable
+//  2. the file named by GH_TOKEN_FILE (trimmed)
+//  3. the keyring entry whose service is GH_TOKEN_KEYRING (macOS Keychain,
+//     or the Secret Service via secret-tool on Linux)
+//  4. "gh auth token"
+//
+// It returns "" if none yields a token. Tokens read from a file or the
+// keyring are registered for redaction, since they are not in the
+// environment.
+func resolveGitHubToken() string {
+	if token := os.Getenv("GH_TOKEN"); token != "" {
+		return token
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	if path := os.Getenv("GH_TOKEN_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if token := strings.TrimSpace(string(data)); err == nil && token != "" {
+			redact.AddSecret(token)
+			return token
+		}
+		logging.Warnf("[auto-pr] Warning: GH_TOKEN_FILE %s: no token read (%v), trying the next source", path, err)
+	}
+	if service := os.Getenv("GH_TOKEN_KEYRING"); service != "" {
+		if token := keyringToken(service); token != "" {
+			redact.AddSecret(token)
+			return token
+		}
+		logging.Warnf("[auto-pr] Warning: no token found in the keyring under GH_TOKEN_KEYRING=%q, trying gh auth token", service)
+	}
+	args := []string{"auth", "token"}
+	if host := ghcli.Host(); host != "" {
+		args = append(args, "--hostname", host)
+	}
+	out, err := exec.Command(ghcli.Path(), args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// keyringToken looks up the password stored under service in the system
+// keyring. Unsupported platforms and lookup failures yield "".
+func keyringToken(service string) string {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", service)
+	default:
+		return ""
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -4,24 +4,42 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"auto-pr/internal/metrics"
+	"auto-pr/internal/redact"
 )
 
 // DefaultTimeout for gh CLI commands.
 const DefaultTimeout = 30 * time.Second
 
+// MinVersion is the oldest gh release auto-pr is known to work with.
+var MinVersion = [3]int{2, 20, 0}
+
 var ghPath string
 
+// ghHost is the GitHub host gh talks to ("" = gh's default).
+var ghHost string
+
+// ghVersion is the detected gh version ({major, minor, patch}), zero if unknown.
+var ghVersion [3]int
+
 // Detect finds the gh CLI binary and returns an error if not found.
+// It also detects the gh version and warns when it is older than MinVersion.
 func Detect() error {
 	// Check PATH first
 	if p, err := exec.LookPath("gh"); err == nil {
 		ghPath = p
+		detectVersion()
 		return nil
 	}
 
@@ -34,6 +52,7 @@ func Detect() error {
 		for _, c := range candidates {
 			if _, err := os.Stat(c); err == nil {
 				ghPath = c
+				detectVersion()
 				return nil
 			}
 		}
@@ -47,35 +66,156 @@ func Path() string {
 	return ghPath
 }
 
-// Run executes a gh command with the given arguments and returns stdout.
-func Run(ctx context.Context, args ...string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+// SetHost points every gh call at host (e.g. a GitHub Enterprise server)
+// by setting GH_HOST. An empty host leaves gh's own default in place.
+func SetHost(host string) {
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	ghHost = strings.TrimSuffix(host, "/")
+}
+
+// Host returns the host set with SetHost, or "".
+func Host() string {
+	return ghHost
+}
+
+var versionRE = regexp.MustCompile(`gh version (\d+)\.(\d+)\.(\d+)`)
+
+// detectVersion runs "gh --version" and warns if gh is older than MinVersion.
+func detectVersion() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	out, err := exec.CommandContext(ctx, ghPath, "--version").Output()
+	if err != nil {
+		return
+	}
+	m := versionRE.FindStringSubmatch(string(out))
+	if m == nil {
+		return
+	}
+	for i := range ghVersion {
+		ghVersion[i], _ = strconv.Atoi(m[i+1])
+	}
+	if !AtLeast(MinVersion[0], MinVersion[1], MinVersion[2]) {
+		fmt.Fprintf(os.Stderr, "[auto-pr] Warning: gh %s is older than the minimum supported %d.%d.%d; some features may misbehave. Upgrade from https://cli.github.com\n",
+			Version(), MinVersion[0], MinVersion[1], MinVersion[2])
+	}
+}
 
-	cmd := exec.CommandContext(ctx, ghPath, args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// Version returns the detected gh version ("2.40.1"), or "unknown".
+func Version() string {
+	if ghVersion == [3]int{} {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d.%d", ghVersion[0], ghVersion[1], ghVersion[2])
+}
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("gh %s: %w\n%s", strings.Join(args, " "), err, stderr.String())
+// AtLeast reports whether the detected gh version is at least major.minor.patch.
+// An undetected version is assumed to be recent.
+func AtLeast(major, minor, patch int) bool {
+	if ghVersion == [3]int{} {
+		return true
 	}
-	return stdout.Bytes(), nil
+	want := [3]int{major, minor, patch}
+	for i := range want {
+		if ghVersion[i] != want[i] {
+			return ghVersion[i] > want[i]
+		}
+	}
+	return true
+}
+
+// AuthFailureThreshold is how many consecutive authentication failures trip
+// the circuit breaker.
+const AuthFailureThreshold = 5
+
+// ErrAuthLost is returned by every gh call once the circuit breaker trips.
+var ErrAuthLost = errors.New("GitHub authentication lost — re-authenticate with 'gh auth login' (or refresh GH_TOKEN) and restart")
+
+var (
+	authMu       sync.Mutex
+	authFailures int
+	authLost     = make(chan struct{})
+)
+
+// AuthLost reports whether the circuit breaker has tripped.
+func AuthLost() bool {
+	select {
+	case <-authLost:
+		return true
+	default:
+		return false
+	}
+}
+
+// AuthLostC returns a channel that is closed when the circuit breaker trips.
+func AuthLostC() <-chan struct{} {
+	return authLost
+}
+
+// isAuthFailure reports whether gh's stderr describes an authentication
+// failure: a 401, bad credentials, or gh asking to log in. Other 403s are
+// rate limits or permission errors ("Resource not accessible by personal
+// access token") of a token that still works.
+func isAuthFailure(stderr string) bool {
+	lower := strings.ToLower(stderr)
+	return strings.Contains(lower, "http 401") ||
+		strings.Contains(lower, "bad credentials") ||
+		strings.Contains(lower, "gh auth login")
+}
+
+// recordResult updates the auth circuit breaker after a gh call.
+func recordResult(err error, stderr string) {
+	authMu.Lock()
+	defer authMu.Unlock()
+	if err == nil {
+		authFailures = 0
+		return
+	}
+	if !isAuthFailure(stderr) {
+		return
+	}
+	authFailures++
+	if authFailures >= AuthFailureThreshold && !AuthLost() {
+		fmt.Fprintf(os.Stderr, "[auto-pr] Error: %d consecutive GitHub authentication failures. %v\n", authFailures, ErrAuthLost)
+		close(authLost)
+	}
+}
+
+// Run executes a gh command with the given arguments and returns stdout.
+func Run(ctx context.Context, args ...string) ([]byte, error) {
+	return run(ctx, nil, args...)
 }
 
 // RunWithStdin executes a gh command with stdin input.
 func RunWithStdin(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	return run(ctx, stdin, args...)
+}
+
+func run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	if AuthLost() {
+		return nil, ErrAuthLost
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
 	defer cancel()
 
+	metrics.GHCalls.Inc()
 	cmd := exec.CommandContext(ctx, ghPath, args...)
-	cmd.Stdin = bytes.NewReader(stdin)
+	if ghHost != "" {
+		cmd.Env = append(os.Environ(), "GH_HOST="+ghHost)
+	}
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("gh %s: %w\n%s", strings.Join(args, " "), err, stderr.String())
+	err := cmd.Run()
+	recordResult(err, stderr.String())
+	if err != nil {
+		// Arguments and stderr can carry tokens; errors end up in logs.
+		return nil, fmt.Errorf("gh %s: %w\n%s", redact.String(strings.Join(args, " ")), err, redact.String(stderr.String()))
 	}
 	return stdout.Bytes(), nil
 }
@@ -112,6 +252,20 @@ func APIPaginateTyped(ctx context.Context, endpoint string, v interface{}, opts
 
 // RepoSlug returns the "owner/repo" for the current repository.
 func RepoSlug(ctx context.Context) (string, error) {
+	// --jq on "gh repo view" needs gh 2.0+; older versions get raw JSON.
+	if !AtLeast(2, 0, 0) {
+		var info struct {
+			NameWithOwner string `json:"nameWithOwner"`
+		}
+		data, err := Run(ctx, "repo", "view", "--json", "nameWithOwner")
+		if err != nil {
+			return "", fmt.Errorf("not inside a GitHub repository: %w", err)
+		}
+		if err := json.Unmarshal(data, &info); err != nil {
+			return "", fmt.Errorf("parse repo view output: %w", err)
+		}
+		return info.NameWithOwner, nil
+	}
 	data, err := Run(ctx, "repo", "view", "--json", "nameWithOwner", "--jq", ".nameWithOwner")
 	if err != nil {
 		return "", fmt.Errorf("not inside a GitHub repository: %w", err)
@@ -0,0 +1,30 @@
+package ghcli
+
+import "testing"
+
+func TestIsAuthFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   bool
+	}{
+		{"401", "gh: Requires authentication (HTTP 401)", true},
+		{"bad credentials", "gh: Bad credentials (HTTP 401)", true},
+		{"bad credentials 403", "HTTP 403: Bad credentials (https://api.github.com/user)", true},
+		{"not logged in", "To get started with GitHub CLI, please run:  gh auth login", true},
+		{"rate limit", "gh: API rate limit exceeded for user ID 1. (HTTP 403)", false},
+		{"secondary rate limit", "HTTP 403: You have exceeded a secondary rate limit and have been temporarily blocked from content creation.", false},
+		{"permission", "gh: Resource not accessible by personal access token (HTTP 403)", false},
+		{"integration permission", "HTTP 403: Resource not accessible by integration (https://api.github.com/repos/o/r/labels)", false},
+		{"token scope", "HTTP 403: Must have admin rights to Repository. This token lacks the required scope.", false},
+		{"not found", "gh: Not Found (HTTP 404)", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAuthFailure(tt.stderr); got != tt.want {
+				t.Errorf("isAuthFailure(%q) = %v, want %v", tt.stderr, got, tt.want)
+			}
+		})
+	}
+}
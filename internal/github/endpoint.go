@@ -0,0 +1,26 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// apiPrefix is prepended to REST paths, for GitHub Enterprise installs
+// served under a subpath or behind a proxy (GITHUB_API_PREFIX).
+var apiPrefix string
+
+// SetAPIPrefix sets the path prefix for REST endpoints ("" = none). The host
+// itself is gh's business (see ghcli.SetHost).
+func SetAPIPrefix(prefix string) {
+	apiPrefix = strings.Trim(prefix, "/")
+}
+
+// restPath builds a REST endpoint for gh api from a format like
+// "repos/%s/pulls/%d", honoring the configured API prefix.
+func restPath(format string, args ...interface{}) string {
+	p := fmt.Sprintf(format, args...)
+	if apiPrefix == "" {
+		return p
+	}
+	return apiPrefix + "/" + p
+}
@@ -0,0 +1,38 @@
+package github
+
+import (
+	"testing"
+
+	"auto-pr/internal/ghcli"
+)
+
+func TestRestPath(t *testing.T) {
+	t.Cleanup(func() {
+		ghcli.SetHost("")
+		SetAPIPrefix("")
+	})
+	tests := []struct {
+		name         string
+		host, prefix string
+		wantHost     string
+		wantPath     string
+	}{
+		{"github.com", "", "", "", "repos/o/r/pulls/7/comments"},
+		{"GHES host", "github.example.com", "", "github.example.com", "repos/o/r/pulls/7/comments"},
+		{"GHES URL", "https://github.example.com/", "", "github.example.com", "repos/o/r/pulls/7/comments"},
+		{"API prefix", "github.example.com", "api/v3", "github.example.com", "api/v3/repos/o/r/pulls/7/comments"},
+		{"API prefix with slashes", "", "/github/api/v3/", "", "github/api/v3/repos/o/r/pulls/7/comments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ghcli.SetHost(tt.host)
+			SetAPIPrefix(tt.prefix)
+			if got := ghcli.Host(); got != tt.wantHost {
+				t.Errorf("host = %q, want %q", got, tt.wantHost)
+			}
+			if got := restPath("repos/%s/pulls/%d/comments", "o/r", 7); got != tt.wantPath {
+				t.Errorf("restPath = %q, want %q", got, tt.wantPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"auto-pr/internal/ghcli"
+)
+
+// CreateGist uploads content as a secret gist and returns its URL.
+func CreateGist(ctx context.Context, filename, description string, content []byte) (string, error) {
+	out, err := ghcli.RunWithStdin(ctx, content, "gist", "create", "-", "--filename", filename, "--desc", description)
+	if err != nil {
+		return "", fmt.Errorf("create gist: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -10,9 +10,10 @@ import (
 )
 
 // FetchIssuesWithLabels fetches open issues matching ANY of the given
-// comma-separated labels (OR logic). Each label triggers a separate API call;
-// results are deduplicated by issue number.
-func FetchIssuesWithLabels(ctx context.Context, repo, labels string) ([]Issue, error) {
+// comma-separated labels (OR logic), dropping issues that carry any of the
+// comma-separated exclude labels. Each include label triggers a separate API
+// call; results are deduplicated by issue number.
+func FetchIssuesWithLabels(ctx context.Context, repo, labels, exclude string) ([]Issue, error) {
 	seen := map[int]bool{}
 	var result []Issue
 
@@ -22,7 +23,7 @@ func FetchIssuesWithLabels(ctx context.Context, repo, labels string) ([]Issue, e
 			continue
 		}
 		encoded := url.QueryEscape(label)
-		endpoint := fmt.Sprintf("repos/%s/issues?labels=%s&state=open&sort=created&direction=asc", repo, encoded)
+		endpoint := restPath("repos/%s/issues?labels=%s&state=open&sort=created&direction=asc", repo, encoded)
 
 		var issues []Issue
 		if err := ghcli.APIPaginateTyped(ctx, endpoint, &issues); err != nil {
@@ -34,18 +35,92 @@ func FetchIssuesWithLabels(ctx context.Context, repo, labels string) ([]Issue, e
 				continue
 			}
 			seen[issue.Number] = true
+			if excluded(&issue, exclude) {
+				continue
+			}
 			result = append(result, issue)
 		}
 	}
 	return result, nil
 }
 
+// excluded reports whether the issue carries any of the comma-separated labels.
+func excluded(issue *Issue, exclude string) bool {
+	for _, label := range strings.Split(exclude, ",") {
+		if label = strings.TrimSpace(label); label != "" && issue.HasLabel(label) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetIssue fetches a single issue by number.
 func GetIssue(ctx context.Context, repo string, num int) (*Issue, error) {
 	var issue Issue
-	err := ghcli.APITyped(ctx, fmt.Sprintf("repos/%s/issues/%d", repo, num), &issue)
+	err := ghcli.APITyped(ctx, restPath("repos/%s/issues/%d", repo, num), &issue)
 	if err != nil {
 		return nil, err
 	}
 	return &issue, nil
 }
+
+// FetchIssueComments fetches all comments on an issue, oldest first.
+func FetchIssueComments(ctx context.Context, repo string, num int) ([]IssueComment, error) {
+	var comments []IssueComment
+	if err := ghcli.APIPaginateTyped(ctx, restPath("repos/%s/issues/%d/comments", repo, num), &comments); err != nil {
+		return nil, fmt.Errorf("fetch issue comments: %w", err)
+	}
+	return comments, nil
+}
+
+// GetIssueComment fetches an issue or PR conversation comment by ID.
+func GetIssueComment(ctx context.Context, repo string, id int) (*IssueComment, error) {
+	var c IssueComment
+	if err := ghcli.APITyped(ctx, restPath("repos/%s/issues/comments/%d", repo, id), &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// PostIssueComment posts a comment on an issue or PR conversation and
+// returns it.
+func PostIssueComment(ctx context.Context, repo string, num int, body string) (*IssueComment, error) {
+	var c IssueComment
+	if err := ghcli.APITyped(ctx, restPath("repos/%s/issues/%d/comments", repo, num), &c, "-f", "body="+body); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CommentOnIssue posts a comment on an issue or PR conversation.
+func CommentOnIssue(ctx context.Context, repo string, num int, body string) error {
+	_, err := ghcli.API(ctx, restPath("repos/%s/issues/%d/comments", repo, num), "-f", "body="+body)
+	return err
+}
+
+// CloseIssue closes an issue as completed.
+func CloseIssue(ctx context.Context, repo string, num int) error {
+	_, err := ghcli.API(ctx, restPath("repos/%s/issues/%d", repo, num), "-X", "PATCH", "-f", "state=closed", "-f", "state_reason=completed")
+	return err
+}
+
+// AddLabels adds labels to an issue or PR. Labels that do not exist yet are
+// created by GitHub.
+func AddLabels(ctx context.Context, repo string, num int, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	args := make([]string, 0, 2*len(labels))
+	for _, l := range labels {
+		args = append(args, "-f", "labels[]="+l)
+	}
+	_, err := ghcli.API(ctx, restPath("repos/%s/issues/%d/labels", repo, num), args...)
+	return err
+}
+
+// ReactToIssueComment adds a reaction ("+1", "-1", "eyes", "confused", ...)
+// to an issue or PR conversation comment.
+func ReactToIssueComment(ctx context.Context, repo string, commentID int, content string) error {
+	_, err := ghcli.API(ctx, restPath("repos/%s/issues/comments/%d/reactions", repo, commentID), "-f", "content="+content)
+	return err
+}
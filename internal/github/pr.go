@@ -3,7 +3,9 @@ package github
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
 
@@ -21,34 +23,246 @@ func CurrentBranch() (string, error) {
 	return strings.TrimSpace(out.String()), nil
 }
 
-// FindPRForBranch finds the open PR number for the given branch.
-func FindPRForBranch(ctx context.Context, repo, branch string) (int, error) {
+// FindPRForBranch finds the open PR number for the given branch. A PR from
+// the repo itself wins over fork PRs whose branch has the same name; fork
+// PRs are only considered with forks (a branch checked out with "gh pr
+// checkout" carries the fork's branch name).
+func FindPRForBranch(ctx context.Context, repo, branch string, forks bool) (int, error) {
 	var pulls []PullRequest
-	if err := ghcli.APIPaginateTyped(ctx, fmt.Sprintf("repos/%s/pulls", repo), &pulls); err != nil {
+	if err := ghcli.APIPaginateTyped(ctx, restPath("repos/%s/pulls", repo), &pulls); err != nil {
 		return 0, fmt.Errorf("fetch PRs: %w", err)
 	}
+	fork := 0
 	for _, pr := range pulls {
-		if pr.Head.Ref == branch {
+		if pr.Head.Ref != branch {
+			continue
+		}
+		if !pr.IsFork(repo) {
 			return pr.Number, nil
 		}
+		if forks && fork == 0 {
+			fork = pr.Number
+		}
+	}
+	if fork > 0 {
+		return fork, nil
 	}
 	return 0, fmt.Errorf("no open PR found for branch '%s'", branch)
 }
 
 // GetPRState returns the state of a PR ("open", "closed", "merged").
 func GetPRState(ctx context.Context, repo string, prNum int) (string, error) {
-	var pr PullRequest
-	err := ghcli.APITyped(ctx, fmt.Sprintf("repos/%s/pulls/%d", repo, prNum), &pr)
+	pr, err := GetPR(ctx, repo, prNum)
 	if err != nil {
 		return "", err
 	}
+	if pr.Merged {
+		return "merged", nil // the API reports merged PRs as "closed"
+	}
 	return pr.State, nil
 }
 
+// GetPR fetches a pull request.
+func GetPR(ctx context.Context, repo string, prNum int) (*PullRequest, error) {
+	var pr PullRequest
+	if err := ghcli.APITyped(ctx, restPath("repos/%s/pulls/%d", repo, prNum), &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// GetPRHeadSHA returns the commit the PR's head branch points at.
+func GetPRHeadSHA(ctx context.Context, repo string, prNum int) (string, error) {
+	pr, err := GetPR(ctx, repo, prNum)
+	if err != nil {
+		return "", err
+	}
+	return pr.Head.SHA, nil
+}
+
+// Check states returned by GetChecksState.
+const (
+	ChecksSuccess = "success"
+	ChecksPending = "pending"
+	ChecksFailure = "failure"
+)
+
+// GetChecksState combines the commit statuses and check runs for sha into
+// one of ChecksSuccess, ChecksPending or ChecksFailure. A commit with no
+// checks at all counts as success.
+func GetChecksState(ctx context.Context, repo, sha string) (string, error) {
+	var status struct {
+		State      string `json:"state"`
+		TotalCount int    `json:"total_count"`
+	}
+	if err := ghcli.APITyped(ctx, restPath("repos/%s/commits/%s/status", repo, sha), &status); err != nil {
+		return "", fmt.Errorf("fetch commit status: %w", err)
+	}
+	runs, err := GetCheckRuns(ctx, repo, sha)
+	if err != nil {
+		return "", err
+	}
+
+	result := ChecksSuccess
+	// The combined status is "pending" when no statuses exist, so only
+	// trust it when there is at least one.
+	if status.TotalCount > 0 {
+		switch status.State {
+		case "failure", "error":
+			return ChecksFailure, nil
+		case "pending":
+			result = ChecksPending
+		}
+	}
+	for i := range runs {
+		if runs[i].Failed() {
+			return ChecksFailure, nil
+		}
+		if runs[i].Status != "completed" {
+			result = ChecksPending
+		}
+	}
+	return result, nil
+}
+
+// GetCheckRuns returns every check run for a commit, across all pages.
+func GetCheckRuns(ctx context.Context, repo, sha string) ([]CheckRun, error) {
+	data, err := ghcli.APIPaginate(ctx, restPath("repos/%s/commits/%s/check-runs?per_page=100", repo, sha))
+	if err != nil {
+		return nil, fmt.Errorf("fetch check runs: %w", err)
+	}
+	return parseCheckRuns(data)
+}
+
+// parseCheckRuns handles the gh api --paginate output for check runs: one
+// {"total_count", "check_runs"} object per page, concatenated. Getting fewer
+// runs than total_count is an error, so a run that was missed can never make
+// the checks look green.
+func parseCheckRuns(data []byte) ([]CheckRun, error) {
+	var runs []CheckRun
+	total := 0
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var page struct {
+			TotalCount int        `json:"total_count"`
+			CheckRuns  []CheckRun `json:"check_runs"`
+		}
+		if err := dec.Decode(&page); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("parse check runs: %w", err)
+		}
+		runs = append(runs, page.CheckRuns...)
+		total = max(total, page.TotalCount)
+	}
+	if len(runs) < total {
+		return nil, fmt.Errorf("fetch check runs: got %d of %d", len(runs), total)
+	}
+	return runs, nil
+}
+
+// GetJobLog returns the tail (at most maxBytes) of a GitHub Actions job's
+// log. Check runs from other CI apps have no log available through the API.
+func GetJobLog(ctx context.Context, repo string, jobID, maxBytes int) (string, error) {
+	data, err := ghcli.API(ctx, restPath("repos/%s/actions/jobs/%d/logs", repo, jobID))
+	if err != nil {
+		return "", fmt.Errorf("fetch job log: %w", err)
+	}
+	if len(data) > maxBytes {
+		data = data[len(data)-maxBytes:]
+	}
+	return string(data), nil
+}
+
+// ReviewDecision reduces reviews to each reviewer's latest decision and
+// reports whether the PR has an approval and whether any change request is
+// still outstanding. Comment-only reviews do not change a decision.
+func ReviewDecision(reviews []Review) (approved, changesRequested bool) {
+	latest := map[string]string{}
+	for _, r := range reviews {
+		switch r.State {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[r.User.Login] = r.State
+		}
+	}
+	for _, state := range latest {
+		switch state {
+		case "APPROVED":
+			approved = true
+		case "CHANGES_REQUESTED":
+			changesRequested = true
+		}
+	}
+	return approved, changesRequested
+}
+
+// MergePR enables auto-merge on a PR with the given method ("squash",
+// "merge" or "rebase"); GitHub merges it as soon as requirements are met.
+func MergePR(ctx context.Context, repo string, prNum int, method string) error {
+	_, err := ghcli.Run(ctx, "pr", "merge", fmt.Sprint(prNum), "--"+method, "--auto", "-R", repo)
+	return err
+}
+
+// MarkPRReady marks a draft PR ready for review. nodeID is the PR's GraphQL
+// node ID.
+func MarkPRReady(ctx context.Context, nodeID string) error {
+	const mutation = `mutation($id: ID!) { markPullRequestReadyForReview(input: {pullRequestId: $id}) { pullRequest { isDraft } } }`
+	if _, err := ghcli.API(ctx, "graphql", "-f", "query="+mutation, "-f", "id="+nodeID); err != nil {
+		return fmt.Errorf("mark PR ready for review: %w", err)
+	}
+	return nil
+}
+
+// RequestReviewers requests reviews on a PR from users (logins) and teams
+// (slugs within the repo's organization).
+func RequestReviewers(ctx context.Context, repo string, prNum int, users, teams []string) error {
+	var args []string
+	for _, u := range users {
+		args = append(args, "-f", "reviewers[]="+u)
+	}
+	for _, t := range teams {
+		args = append(args, "-f", "team_reviewers[]="+t)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	if _, err := ghcli.API(ctx, restPath("repos/%s/pulls/%d/requested_reviewers", repo, prNum), args...); err != nil {
+		return fmt.Errorf("request reviewers: %w", err)
+	}
+	return nil
+}
+
+// CreatePR opens a pull request from head into base and returns its number.
+func CreatePR(ctx context.Context, repo, head, base, title, body string, draft bool) (int, error) {
+	var pr PullRequest
+	err := ghcli.APITyped(ctx, restPath("repos/%s/pulls", repo), &pr,
+		"-f", "head="+head, "-f", "base="+base, "-f", "title="+title, "-f", "body="+body,
+		"-F", fmt.Sprintf("draft=%t", draft))
+	if err != nil {
+		return 0, fmt.Errorf("create PR: %w", err)
+	}
+	return pr.Number, nil
+}
+
+// HasChecks reports whether any commit status or check run exists for sha.
+func HasChecks(ctx context.Context, repo, sha string) (bool, error) {
+	var status struct {
+		TotalCount int `json:"total_count"`
+	}
+	if err := ghcli.APITyped(ctx, restPath("repos/%s/commits/%s/status", repo, sha), &status); err != nil {
+		return false, fmt.Errorf("fetch commit status: %w", err)
+	}
+	if status.TotalCount > 0 {
+		return true, nil
+	}
+	runs, err := GetCheckRuns(ctx, repo, sha)
+	return len(runs) > 0, err
+}
+
 // GetDefaultBranch returns the default branch of the repo.
 func GetDefaultBranch(ctx context.Context, repo string) (string, error) {
 	var info RepoInfo
-	err := ghcli.APITyped(ctx, fmt.Sprintf("repos/%s", repo), &info)
+	err := ghcli.APITyped(ctx, restPath("repos/%s", repo), &info)
 	if err != nil {
 		return "main", nil
 	}
@@ -57,3 +271,26 @@ func GetDefaultBranch(ctx context.Context, repo string) (string, error) {
 	}
 	return info.DefaultBranch, nil
 }
+
+// GetReview fetches one review of a PR.
+func GetReview(ctx context.Context, repo string, prNum, id int) (*Review, error) {
+	var review Review
+	if err := ghcli.APITyped(ctx, restPath("repos/%s/pulls/%d/reviews/%d", repo, prNum, id), &review); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// SubmitReview submits a review on a PR. event is one of "APPROVE",
+// "REQUEST_CHANGES" or "COMMENT".
+func SubmitReview(ctx context.Context, repo string, prNum int, event, body string) (*Review, error) {
+	opts := []string{"-X", "POST", "-f", "event=" + event}
+	if body != "" {
+		opts = append(opts, "-f", "body="+body)
+	}
+	var review Review
+	if err := ghcli.APITyped(ctx, restPath("repos/%s/pulls/%d/reviews", repo, prNum), &review, opts...); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
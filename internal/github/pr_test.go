@@ -0,0 +1,30 @@
+package github
+
+import "testing"
+
+func TestParseCheckRuns(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{"one page", `{"total_count":2,"check_runs":[{"id":1},{"id":2}]}`, 2, false},
+		{"two pages", `{"total_count":3,"check_runs":[{"id":1},{"id":2}]}
+{"total_count":3,"check_runs":[{"id":3}]}`, 3, false},
+		{"none", `{"total_count":0,"check_runs":[]}`, 0, false},
+		{"missing runs", `{"total_count":150,"check_runs":[{"id":1}]}`, 0, true},
+		{"garbage", `not json`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, err := parseCheckRuns([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if len(runs) != tt.want {
+				t.Errorf("got %d runs, want %d", len(runs), tt.want)
+			}
+		})
+	}
+}
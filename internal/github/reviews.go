@@ -2,15 +2,19 @@ package github
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"auto-pr/internal/ghcli"
 )
 
 // FetchReviewComments fetches all inline (line-level) comments on a PR.
 func FetchReviewComments(ctx context.Context, repo string, prNum int) ([]ReviewComment, error) {
-	data, err := ghcli.APIPaginate(ctx, fmt.Sprintf("repos/%s/pulls/%d/comments", repo, prNum))
+	data, err := ghcli.APIPaginate(ctx, restPath("repos/%s/pulls/%d/comments", repo, prNum))
 	if err != nil {
 		return nil, fmt.Errorf("fetch review comments: %w", err)
 	}
@@ -19,7 +23,7 @@ func FetchReviewComments(ctx context.Context, repo string, prNum int) ([]ReviewC
 
 // FetchReviews fetches all top-level reviews on a PR.
 func FetchReviews(ctx context.Context, repo string, prNum int) ([]Review, error) {
-	data, err := ghcli.APIPaginate(ctx, fmt.Sprintf("repos/%s/pulls/%d/reviews", repo, prNum))
+	data, err := ghcli.APIPaginate(ctx, restPath("repos/%s/pulls/%d/reviews", repo, prNum))
 	if err != nil {
 		return nil, fmt.Errorf("fetch reviews: %w", err)
 	}
@@ -89,40 +93,212 @@ func FilterLatestReview(reviews []Review, comments []ReviewComment) ([]Review, [
 	return filteredReviews, filteredComments
 }
 
-// GetLatestCommentTimestamp returns the latest timestamp across all comments and reviews.
-func GetLatestCommentTimestamp(ctx context.Context, repo string, prNum int) (string, error) {
+// FilterByUser keeps comments and reviews authored by login (case-insensitive).
+func FilterByUser(reviews []Review, comments []ReviewComment, login string) ([]Review, []ReviewComment) {
+	var filteredReviews []Review
+	for _, r := range reviews {
+		if strings.EqualFold(r.User.Login, login) {
+			filteredReviews = append(filteredReviews, r)
+		}
+	}
+	var filteredComments []ReviewComment
+	for _, c := range comments {
+		if strings.EqualFold(c.User.Login, login) {
+			filteredComments = append(filteredComments, c)
+		}
+	}
+	return filteredReviews, filteredComments
+}
+
+// FilterByState keeps reviews in state (e.g. "CHANGES_REQUESTED",
+// case-insensitive) and the inline comments belonging to them.
+func FilterByState(reviews []Review, comments []ReviewComment, state string) ([]Review, []ReviewComment) {
+	var filteredReviews []Review
+	keep := map[int]bool{}
+	for _, r := range reviews {
+		if strings.EqualFold(r.State, state) {
+			filteredReviews = append(filteredReviews, r)
+			keep[r.ID] = true
+		}
+	}
+	var filteredComments []ReviewComment
+	for _, c := range comments {
+		if keep[c.PullRequestReviewID] {
+			filteredComments = append(filteredComments, c)
+		}
+	}
+	return filteredReviews, filteredComments
+}
+
+// FilterSince keeps comments updated and reviews submitted at or after
+// since. Timestamps have one-second resolution, so the boundary is
+// inclusive: anything else posted in the same second as since is kept.
+func FilterSince(reviews []Review, comments []ReviewComment, since time.Time) ([]Review, []ReviewComment) {
+	var filteredReviews []Review
+	for _, r := range reviews {
+		if !r.SubmittedTime().Before(since) {
+			filteredReviews = append(filteredReviews, r)
+		}
+	}
+	var filteredComments []ReviewComment
+	for _, c := range comments {
+		if !c.LatestTime().Before(since) {
+			filteredComments = append(filteredComments, c)
+		}
+	}
+	return filteredReviews, filteredComments
+}
+
+// Cursor marks how far a PR's comments and reviews have been processed.
+// GitHub IDs grow monotonically, so anything with a larger ID is new.
+type Cursor struct {
+	CommentID int
+	ReviewID  int
+	// Timestamp is the latest comment/review time seen, for display only;
+	// it plays no part in deciding what is new.
+	Timestamp time.Time
+}
+
+// FilterAfter keeps comments and reviews with IDs beyond the cursor.
+func FilterAfter(reviews []Review, comments []ReviewComment, cur Cursor) ([]Review, []ReviewComment) {
+	var filteredReviews []Review
+	for _, r := range reviews {
+		if r.ID > cur.ReviewID {
+			filteredReviews = append(filteredReviews, r)
+		}
+	}
+	var filteredComments []ReviewComment
+	for _, c := range comments {
+		if c.ID > cur.CommentID {
+			filteredComments = append(filteredComments, c)
+		}
+	}
+	return filteredReviews, filteredComments
+}
+
+// LatestCursor returns the cursor just past the given comments and reviews.
+func LatestCursor(reviews []Review, comments []ReviewComment) Cursor {
+	var cur Cursor
+	for _, c := range comments {
+		if c.ID > cur.CommentID {
+			cur.CommentID = c.ID
+		}
+		if ts := c.LatestTime(); ts.After(cur.Timestamp) {
+			cur.Timestamp = ts
+		}
+	}
+	for _, r := range reviews {
+		if r.ID > cur.ReviewID {
+			cur.ReviewID = r.ID
+		}
+		if ts := r.SubmittedTime(); ts.After(cur.Timestamp) {
+			cur.Timestamp = ts
+		}
+	}
+	return cur
+}
+
+// Advance returns the cursor moved past the comments and reviews in data. It
+// never moves back, and anything not in data (newer comments, or replies
+// FetchNewComments filtered out) stays beyond it.
+func (cur Cursor) Advance(data *NewComments) Cursor {
+	if data == nil {
+		return cur
+	}
+	next := LatestCursor(data.TopLevelReviews, data.InlineComments)
+	if next.CommentID < cur.CommentID {
+		next.CommentID = cur.CommentID
+	}
+	if next.ReviewID < cur.ReviewID {
+		next.ReviewID = cur.ReviewID
+	}
+	if next.Timestamp.Before(cur.Timestamp) {
+		next.Timestamp = cur.Timestamp
+	}
+	return next
+}
+
+// GetLatestCursor returns the cursor covering every current comment and
+// review on a PR, used as the baseline on first run and after each round.
+func GetLatestCursor(ctx context.Context, repo string, prNum int) (Cursor, error) {
 	comments, err := FetchReviewComments(ctx, repo, prNum)
 	if err != nil {
-		comments = nil
+		return Cursor{}, err
 	}
 	reviews, err := FetchReviews(ctx, repo, prNum)
 	if err != nil {
-		reviews = nil
+		return Cursor{}, err
+	}
+	return LatestCursor(reviews, comments), nil
+}
+
+// GetCursorAsOf returns the cursor covering comments and reviews older than
+// ts. It migrates state recorded before ID cursors existed. Those at exactly
+// ts are left beyond the cursor: a comment posted in the same second as the
+// one that set ts would otherwise be skipped forever. The one that set it is
+// delivered again, unless its ID is recorded as handled (see dropHandled).
+func GetCursorAsOf(ctx context.Context, repo string, prNum int, ts time.Time) (Cursor, error) {
+	comments, err := FetchReviewComments(ctx, repo, prNum)
+	if err != nil {
+		return Cursor{}, err
 	}
+	reviews, err := FetchReviews(ctx, repo, prNum)
+	if err != nil {
+		return Cursor{}, err
+	}
+	return cursorAsOf(reviews, comments, ts), nil
+}
 
-	var maxTS string
+// cursorAsOf is GetCursorAsOf on comments and reviews already fetched.
+func cursorAsOf(reviews []Review, comments []ReviewComment, ts time.Time) Cursor {
+	newerReviews, newerComments := FilterSince(reviews, comments, ts)
+	skipC := make(map[int]bool, len(newerComments))
+	for _, c := range newerComments {
+		skipC[c.ID] = true
+	}
+	skipR := make(map[int]bool, len(newerReviews))
+	for _, r := range newerReviews {
+		skipR[r.ID] = true
+	}
+	var oldComments []ReviewComment
 	for _, c := range comments {
-		ts := c.LatestTimestamp()
-		if ts > maxTS {
-			maxTS = ts
+		if !skipC[c.ID] {
+			oldComments = append(oldComments, c)
 		}
 	}
+	var oldReviews []Review
 	for _, r := range reviews {
-		if r.SubmittedAt > maxTS {
-			maxTS = r.SubmittedAt
+		if !skipR[r.ID] {
+			oldReviews = append(oldReviews, r)
 		}
 	}
-	return maxTS, nil
+	return LatestCursor(oldReviews, oldComments)
 }
 
-// NewComments holds new inline comments and top-level reviews since a given timestamp.
+// GetLatestCommentTimestamp returns the latest time across all comments and
+// reviews, or the zero time if there are none.
+func GetLatestCommentTimestamp(ctx context.Context, repo string, prNum int) (time.Time, error) {
+	comments, err := FetchReviewComments(ctx, repo, prNum)
+	if err != nil {
+		comments = nil
+	}
+	reviews, err := FetchReviews(ctx, repo, prNum)
+	if err != nil {
+		reviews = nil
+	}
+	return LatestCursor(reviews, comments).Timestamp, nil
+}
+
+// NewComments holds inline comments and top-level reviews newer than a cursor.
 type NewComments struct {
 	InlineComments  []ReviewComment `json:"inline_comments"`
 	TopLevelReviews []Review        `json:"top_level_reviews"`
 }
 
-// FetchNewComments fetches comments and reviews newer than 'since'.
-func FetchNewComments(ctx context.Context, repo string, prNum int, since string) (*NewComments, error) {
+// FetchNewComments fetches comments and reviews with IDs beyond the cursor.
+// Anything authored by ignoreLogin (the bot's own replies) is dropped; an
+// empty ignoreLogin keeps everything.
+func FetchNewComments(ctx context.Context, repo string, prNum int, since Cursor, ignoreLogin string) (*NewComments, error) {
 	comments, err := FetchReviewComments(ctx, repo, prNum)
 	if err != nil {
 		comments = nil
@@ -132,16 +308,17 @@ func FetchNewComments(ctx context.Context, repo string, prNum int, since string)
 		reviews = nil
 	}
 
+	reviews, comments = FilterAfter(reviews, comments, since)
+
 	var newComments []ReviewComment
 	for _, c := range comments {
-		if c.LatestTimestamp() > since {
+		if !isLogin(c.User, ignoreLogin) {
 			newComments = append(newComments, c)
 		}
 	}
-
 	var newReviews []Review
 	for _, r := range reviews {
-		if r.SubmittedAt > since && r.Body != "" {
+		if r.Body != "" && !isLogin(r.User, ignoreLogin) {
 			newReviews = append(newReviews, r)
 		}
 	}
@@ -155,3 +332,50 @@ func FetchNewComments(ctx context.Context, repo string, prNum int, since string)
 		TopLevelReviews: newReviews,
 	}, nil
 }
+
+// isLogin reports whether u is the given (non-empty) login.
+func isLogin(u User, login string) bool {
+	return login != "" && strings.EqualFold(u.Login, login)
+}
+
+// Without returns a copy of n with the comments and reviews for which the skip
+// functions return true removed. Returns nil if nothing remains. Either skip
+// function may be nil.
+func (n *NewComments) Without(skipComment func(*ReviewComment) bool, skipReview func(*Review) bool) *NewComments {
+	if n == nil {
+		return nil
+	}
+	out := &NewComments{}
+	for i := range n.InlineComments {
+		if skipComment == nil || !skipComment(&n.InlineComments[i]) {
+			out.InlineComments = append(out.InlineComments, n.InlineComments[i])
+		}
+	}
+	for i := range n.TopLevelReviews {
+		if skipReview == nil || !skipReview(&n.TopLevelReviews[i]) {
+			out.TopLevelReviews = append(out.TopLevelReviews, n.TopLevelReviews[i])
+		}
+	}
+	if len(out.InlineComments) == 0 && len(out.TopLevelReviews) == 0 {
+		return nil
+	}
+	return out
+}
+
+// Fingerprint returns a content hash of (path, line, normalized body).
+// Normalization only trims and collapses whitespace, so comments that differ
+// in wording or case are still treated as distinct.
+func (c *ReviewComment) Fingerprint() string {
+	return fingerprint(c.Path, c.LineDisplay(), c.Body)
+}
+
+// Fingerprint returns a content hash of the review's normalized body.
+func (r *Review) Fingerprint() string {
+	return fingerprint("", "", r.Body)
+}
+
+func fingerprint(path, line, body string) string {
+	normalized := strings.Join(strings.Fields(body), " ")
+	sum := sha256.Sum256([]byte(path + "\x00" + line + "\x00" + normalized))
+	return hex.EncodeToString(sum[:16])
+}
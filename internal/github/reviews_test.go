@@ -0,0 +1,179 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func commentIDs(comments []ReviewComment) []int {
+	var ids []int
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func reviewIDs(reviews []Review) []int {
+	var ids []int
+	for _, r := range reviews {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFilterSince(t *testing.T) {
+	since := ParseTime("2024-05-01T10:00:00Z")
+	comments := []ReviewComment{
+		{ID: 1, CreatedAt: "2024-05-01T09:59:59Z"},
+		{ID: 2, CreatedAt: "2024-05-01T10:00:00Z"},                                    // at the boundary
+		{ID: 3, CreatedAt: "2024-05-01T09:00:00Z", UpdatedAt: "2024-05-01T10:00:00Z"}, // edited at the boundary
+		{ID: 4, CreatedAt: "2024-05-01T10:00:01Z"},
+	}
+	reviews := []Review{
+		{ID: 10, SubmittedAt: "2024-05-01T09:59:59Z"},
+		{ID: 11, SubmittedAt: "2024-05-01T10:00:00Z"},
+		{ID: 12, SubmittedAt: "2024-05-01T10:00:01Z"},
+	}
+
+	gotReviews, gotComments := FilterSince(reviews, comments, since)
+	if got, want := commentIDs(gotComments), []int{2, 3, 4}; !equalIDs(got, want) {
+		t.Errorf("comments = %v, want %v", got, want)
+	}
+	if got, want := reviewIDs(gotReviews), []int{11, 12}; !equalIDs(got, want) {
+		t.Errorf("reviews = %v, want %v", got, want)
+	}
+}
+
+func TestFilterAfter(t *testing.T) {
+	comments := []ReviewComment{{ID: 9}, {ID: 10}, {ID: 11}}
+	reviews := []Review{{ID: 3}, {ID: 4}}
+
+	gotReviews, gotComments := FilterAfter(reviews, comments, Cursor{CommentID: 10, ReviewID: 3})
+	if got, want := commentIDs(gotComments), []int{11}; !equalIDs(got, want) {
+		t.Errorf("comments = %v, want %v", got, want)
+	}
+	if got, want := reviewIDs(gotReviews), []int{4}; !equalIDs(got, want) {
+		t.Errorf("reviews = %v, want %v", got, want)
+	}
+}
+
+// Two comments posted in the same second as the migrated timestamp must both
+// stay beyond the cursor, even though only one of them set it.
+func TestCursorAsOfSameSecond(t *testing.T) {
+	ts := ParseTime("2024-05-01T10:00:00Z")
+	comments := []ReviewComment{
+		{ID: 5, UpdatedAt: "2024-05-01T09:59:00Z"},
+		{ID: 6, UpdatedAt: "2024-05-01T10:00:00Z"},
+		{ID: 7, UpdatedAt: "2024-05-01T10:00:00Z"},
+	}
+	reviews := []Review{
+		{ID: 20, SubmittedAt: "2024-05-01T09:00:00Z"},
+		{ID: 21, SubmittedAt: "2024-05-01T10:00:00Z"},
+	}
+
+	cur := cursorAsOf(reviews, comments, ts)
+	if cur.CommentID != 5 || cur.ReviewID != 20 {
+		t.Fatalf("cursor = comment #%d / review #%d, want #5 / #20", cur.CommentID, cur.ReviewID)
+	}
+	if want := ParseTime("2024-05-01T09:59:00Z"); !cur.Timestamp.Equal(want) {
+		t.Errorf("cursor timestamp = %v, want %v", cur.Timestamp, want)
+	}
+
+	gotReviews, gotComments := FilterAfter(reviews, comments, cur)
+	if got, want := commentIDs(gotComments), []int{6, 7}; !equalIDs(got, want) {
+		t.Errorf("comments after cursor = %v, want %v", got, want)
+	}
+	if got, want := reviewIDs(gotReviews), []int{21}; !equalIDs(got, want) {
+		t.Errorf("reviews after cursor = %v, want %v", got, want)
+	}
+}
+
+func TestCursorAsOfNothingOlder(t *testing.T) {
+	comments := []ReviewComment{{ID: 6, UpdatedAt: "2024-05-01T10:00:00Z"}}
+	cur := cursorAsOf(nil, comments, ParseTime("2024-05-01T10:00:00Z"))
+	if cur != (Cursor{}) {
+		t.Errorf("cursor = %+v, want the zero cursor", cur)
+	}
+}
+
+func TestCursorAdvance(t *testing.T) {
+	start := Cursor{CommentID: 10, ReviewID: 5, Timestamp: ParseTime("2024-05-01T10:00:00Z")}
+
+	if got := start.Advance(nil); got != start {
+		t.Errorf("Advance(nil) = %+v, want %+v", got, start)
+	}
+
+	got := start.Advance(&NewComments{
+		InlineComments:  []ReviewComment{{ID: 12, UpdatedAt: "2024-05-01T09:00:00Z"}},
+		TopLevelReviews: []Review{{ID: 4, SubmittedAt: "2024-05-01T09:00:00Z"}},
+	})
+	if got.CommentID != 12 || got.ReviewID != 5 {
+		t.Errorf("cursor = comment #%d / review #%d, want #12 / #5", got.CommentID, got.ReviewID)
+	}
+	if !got.Timestamp.Equal(start.Timestamp) {
+		t.Errorf("timestamp moved back to %v", got.Timestamp)
+	}
+}
+
+func TestLatestCursor(t *testing.T) {
+	comments := []ReviewComment{
+		{ID: 3, CreatedAt: "2024-05-01T10:00:00Z"},
+		{ID: 8, CreatedAt: "2024-05-01T09:00:00Z", UpdatedAt: "2024-05-01T11:00:00Z"},
+	}
+	reviews := []Review{{ID: 40, SubmittedAt: "2024-05-01T10:30:00Z"}}
+	cur := LatestCursor(reviews, comments)
+	want := Cursor{CommentID: 8, ReviewID: 40, Timestamp: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)}
+	if cur.CommentID != want.CommentID || cur.ReviewID != want.ReviewID || !cur.Timestamp.Equal(want.Timestamp) {
+		t.Errorf("LatestCursor = %+v, want %+v", cur, want)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	const base = "Please rename this variable."
+	tests := []struct {
+		name       string
+		path, line string
+		body       string
+		same       bool
+	}{
+		{"identical", "main.go", "10", base, true},
+		{"extra spaces", "main.go", "10", "Please  rename   this variable.", true},
+		{"surrounding whitespace", "main.go", "10", "\n  " + base + "\t\n", true},
+		{"line breaks", "main.go", "10", "Please rename\nthis variable.", true},
+		{"CRLF", "main.go", "10", "Please rename\r\nthis variable.", true},
+		{"different wording", "main.go", "10", "Please rename this field.", false},
+		{"different case", "main.go", "10", "please rename this variable.", false},
+		{"different punctuation", "main.go", "10", "Please rename this variable!", false},
+		{"different line", "main.go", "11", base, false},
+		{"different path", "util.go", "10", base, false},
+	}
+	want := fingerprint("main.go", "10", base)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fingerprint(tt.path, tt.line, tt.body)
+			if (got == want) != tt.same {
+				t.Errorf("fingerprint(%q, %q, %q) same = %v, want %v", tt.path, tt.line, tt.body, got == want, tt.same)
+			}
+		})
+	}
+}
+
+// Path and line are separated, so moving text between them changes the
+// fingerprint.
+func TestFingerprintFieldBoundaries(t *testing.T) {
+	if fingerprint("a", "1", "x") == fingerprint("a1", "", "x") {
+		t.Error("path and line run together")
+	}
+}
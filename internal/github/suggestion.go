@@ -0,0 +1,121 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"auto-pr/internal/ghcli"
+)
+
+// ParseSuggestion extracts the replacement text from a comment body holding
+// exactly one ```suggestion block. The returned lines replace the commented
+// line(s); an empty slice means "delete". ok is false when the body holds no
+// suggestion, more than one, or an unterminated block.
+func ParseSuggestion(body string) (lines []string, ok bool) {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	var found bool
+	inBlock := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !inBlock {
+			if trimmed == "```suggestion" {
+				if found {
+					return nil, false // multiple suggestions are ambiguous
+				}
+				found, inBlock = true, true
+				lines = []string{}
+			}
+			continue
+		}
+		if trimmed == "```" {
+			inBlock = false
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if !found || inBlock {
+		return nil, false
+	}
+	return lines, true
+}
+
+// HasSuggestion reports whether a comment body holds a ```suggestion block,
+// even one ParseSuggestion would reject.
+func HasSuggestion(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) == "```suggestion" {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestionBody wraps code in a ```suggestion block, which GitHub renders as
+// a one-click change replacing the commented line(s).
+func SuggestionBody(code string) string {
+	code = strings.TrimSuffix(code, "\n")
+	if code == "" {
+		return "```suggestion\n```" // delete the lines
+	}
+	return "```suggestion\n" + code + "\n```"
+}
+
+// HunkLastLine returns the final new-side line of a diff hunk — the line a
+// single-line review comment is attached to — without its diff prefix.
+func HunkLastLine(hunk string) (string, bool) {
+	hunkLines := strings.Split(strings.TrimRight(hunk, "\n"), "\n")
+	for i := len(hunkLines) - 1; i >= 0; i-- {
+		l := hunkLines[i]
+		if strings.HasPrefix(l, "-") || strings.HasPrefix(l, `\`) {
+			continue
+		}
+		if strings.HasPrefix(l, "@@") {
+			return "", false
+		}
+		if l == "" {
+			return "", true
+		}
+		return l[1:], true
+	}
+	return "", false
+}
+
+// ReplyToComment posts a reply to an inline review comment.
+func ReplyToComment(ctx context.Context, repo string, commentID int, body string) (*ReplyResponse, error) {
+	var resp ReplyResponse
+	endpoint := restPath("repos/%s/pulls/comments/%d/replies", repo, commentID)
+	if err := ghcli.APITyped(ctx, endpoint, &resp, "-f", "body="+body); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetReviewComment fetches an inline review comment by ID.
+func GetReviewComment(ctx context.Context, repo string, id int) (*ReviewComment, error) {
+	var c ReviewComment
+	if err := ghcli.APITyped(ctx, restPath("repos/%s/pulls/comments/%d", repo, id), &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CommentOnLines posts a new inline review comment on lines start-end (new
+// side) of path at commit, starting a thread of its own.
+func CommentOnLines(ctx context.Context, repo string, prNum int, commit, path string, start, end int, body string) (*ReplyResponse, error) {
+	var resp ReplyResponse
+	opts := []string{
+		"-f", "body=" + body,
+		"-f", "commit_id=" + commit,
+		"-f", "path=" + path,
+		"-F", fmt.Sprintf("line=%d", end),
+		"-f", "side=RIGHT",
+	}
+	if start < end {
+		opts = append(opts, "-F", fmt.Sprintf("start_line=%d", start), "-f", "start_side=RIGHT")
+	}
+	if err := ghcli.APITyped(ctx, restPath("repos/%s/pulls/%d/comments", repo, prNum), &resp, opts...); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
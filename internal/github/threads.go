@@ -0,0 +1,87 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"auto-pr/internal/ghcli"
+)
+
+// reviewThreadsQuery pages through a PR's review threads with the database
+// IDs of their comments. The REST API has no notion of resolved threads.
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $after) {
+        nodes {
+          isResolved
+          comments(first: 100) { nodes { databaseId } }
+        }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}`
+
+type reviewThreadsResponse struct {
+	Data struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewThreads struct {
+					Nodes []struct {
+						IsResolved bool `json:"isResolved"`
+						Comments   struct {
+							Nodes []struct {
+								DatabaseID int `json:"databaseId"`
+							} `json:"nodes"`
+						} `json:"comments"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"reviewThreads"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	} `json:"data"`
+}
+
+// UnresolvedCommentIDs returns the IDs of the review comments in the PR's
+// unresolved threads.
+func UnresolvedCommentIDs(ctx context.Context, repo string, prNum int) (map[int]bool, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repo %q", repo)
+	}
+	ids := map[int]bool{}
+	after := ""
+	for {
+		args := []string{
+			"-f", "query=" + reviewThreadsQuery,
+			"-f", "owner=" + owner,
+			"-f", "name=" + name,
+			"-F", fmt.Sprintf("number=%d", prNum),
+		}
+		if after != "" {
+			args = append(args, "-f", "after="+after)
+		}
+		var resp reviewThreadsResponse
+		if err := ghcli.APITyped(ctx, "graphql", &resp, args...); err != nil {
+			return nil, fmt.Errorf("fetch review threads: %w", err)
+		}
+		threads := resp.Data.Repository.PullRequest.ReviewThreads
+		for _, t := range threads.Nodes {
+			if t.IsResolved {
+				continue
+			}
+			for _, c := range t.Comments.Nodes {
+				ids[c.DatabaseID] = true
+			}
+		}
+		if !threads.PageInfo.HasNextPage {
+			return ids, nil
+		}
+		after = threads.PageInfo.EndCursor
+	}
+}
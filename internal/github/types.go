@@ -1,6 +1,10 @@
 package github
 
-import "strconv"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // User represents a GitHub user.
 type User struct {
@@ -13,22 +17,56 @@ type ReviewComment struct {
 	Path                string `json:"path"`
 	Line                *int   `json:"line"`
 	OriginalLine        *int   `json:"original_line"`
+	StartLine           *int   `json:"start_line"`          // first line of a multi-line comment
+	OriginalStartLine   *int   `json:"original_start_line"` // StartLine before the code moved
+	Side                string `json:"side"`                // "RIGHT" (new code) or "LEFT" (removed code)
+	StartSide           string `json:"start_side"`
 	Body                string `json:"body"`
+	DiffHunk            string `json:"diff_hunk"`
+	HTMLURL             string `json:"html_url"`
 	User                User   `json:"user"`
 	CreatedAt           string `json:"created_at"`
 	UpdatedAt           string `json:"updated_at"`
 	PullRequestReviewID int    `json:"pull_request_review_id"`
+	PullRequestURL      string `json:"pull_request_url"`
 }
 
-// LineDisplay returns the best available line number as a string.
+// Span returns the first and last line the comment covers (equal for a
+// single-line comment), preferring current lines over original ones.
+// ok is false if the comment has no line at all.
+func (c *ReviewComment) Span() (start, end int, ok bool) {
+	var startLine *int
+	switch {
+	case c.Line != nil:
+		end, startLine = *c.Line, c.StartLine
+	case c.OriginalLine != nil:
+		end, startLine = *c.OriginalLine, c.OriginalStartLine
+	default:
+		return 0, 0, false
+	}
+	start = end
+	if startLine != nil && *startLine < end {
+		start = *startLine
+	}
+	return start, end, true
+}
+
+// LineDisplay returns the best available line number (or "start-end" range)
+// as a string.
 func (c *ReviewComment) LineDisplay() string {
-	if c.Line != nil {
-		return itoa(*c.Line)
+	start, end, ok := c.Span()
+	if !ok {
+		return "?"
 	}
-	if c.OriginalLine != nil {
-		return itoa(*c.OriginalLine)
+	if start != end {
+		return itoa(start) + "-" + itoa(end)
 	}
-	return "?"
+	return itoa(end)
+}
+
+// PRNumber extracts the PR number from PullRequestURL, or 0 if unknown.
+func (c *ReviewComment) PRNumber() int {
+	return urlNumber(c.PullRequestURL)
 }
 
 // LatestTimestamp returns the most recent timestamp for this comment.
@@ -39,6 +77,11 @@ func (c *ReviewComment) LatestTimestamp() string {
 	return c.CreatedAt
 }
 
+// LatestTime returns LatestTimestamp parsed, or the zero time if unparseable.
+func (c *ReviewComment) LatestTime() time.Time {
+	return ParseTime(c.LatestTimestamp())
+}
+
 // Review represents a top-level PR review.
 type Review struct {
 	ID          int    `json:"id"`
@@ -48,30 +91,145 @@ type Review struct {
 	SubmittedAt string `json:"submitted_at"`
 }
 
+// SubmittedTime returns SubmittedAt parsed, or the zero time if unparseable.
+func (r *Review) SubmittedTime() time.Time {
+	return ParseTime(r.SubmittedAt)
+}
+
+// Label represents a GitHub issue label.
+type Label struct {
+	Name string `json:"name"`
+}
+
 // Issue represents a GitHub issue.
 type Issue struct {
-	Number      int    `json:"number"`
-	Title       string `json:"title"`
-	Body        string `json:"body"`
-	State       string `json:"state"`
+	Number      int     `json:"number"`
+	Title       string  `json:"title"`
+	Body        string  `json:"body"`
+	State       string  `json:"state"`
+	Labels      []Label `json:"labels"`
 	PullRequest *struct {
 		URL string `json:"url"`
 	} `json:"pull_request"`
 }
 
+// IssueComment is a comment on an issue or PR conversation.
+type IssueComment struct {
+	ID                int    `json:"id"`
+	Body              string `json:"body"`
+	User              User   `json:"user"`
+	AuthorAssociation string `json:"author_association"` // OWNER, MEMBER, COLLABORATOR, ...
+	CreatedAt         string `json:"created_at"`
+	IssueURL          string `json:"issue_url,omitempty"`
+}
+
+// IssueNumber extracts the issue (or PR) number from IssueURL, or 0 if
+// unknown.
+func (c *IssueComment) IssueNumber() int {
+	return urlNumber(c.IssueURL)
+}
+
+// LabelNames returns the names of the issue's labels.
+func (i *Issue) LabelNames() []string {
+	names := make([]string, 0, len(i.Labels))
+	for _, l := range i.Labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// HasLabel reports whether the issue carries the label (case-insensitive,
+// like GitHub itself).
+func (i *Issue) HasLabel(name string) bool {
+	for _, l := range i.Labels {
+		if strings.EqualFold(l.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // PullRequest represents a GitHub pull request.
 type PullRequest struct {
 	Number int    `json:"number"`
 	State  string `json:"state"`
+	NodeID string `json:"node_id"`
+	Draft  bool   `json:"draft"`
+	Merged bool   `json:"merged"`
+	User   User   `json:"user"`
 	Head   struct {
 		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+		// Repo is the repository the head branch lives in; nil when it
+		// has been deleted.
+		Repo *struct {
+			FullName string `json:"full_name"`
+		} `json:"repo"`
 	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+// IsFork reports whether the PR's head branch lives outside repo (a fork,
+// possibly deleted), so it cannot be fetched from or pushed to origin.
+func (pr *PullRequest) IsFork(repo string) bool {
+	return pr.Head.Repo == nil || !strings.EqualFold(pr.Head.Repo.FullName, repo)
+}
+
+// HeadRepo returns the full name of the head repository, or "(deleted)".
+func (pr *PullRequest) HeadRepo() string {
+	if pr.Head.Repo == nil {
+		return "(deleted)"
+	}
+	return pr.Head.Repo.FullName
+}
+
+// CheckRun represents a check run on a commit. For GitHub Actions, the ID is
+// also the workflow job ID.
+type CheckRun struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HTMLURL    string `json:"html_url"`
+	App        struct {
+		Slug string `json:"slug"`
+	} `json:"app"`
+}
+
+// Failed reports whether the run completed unsuccessfully.
+func (r *CheckRun) Failed() bool {
+	if r.Status != "completed" {
+		return false
+	}
+	switch r.Conclusion {
+	case "success", "neutral", "skipped":
+		return false
+	}
+	return true
 }
 
 // ReplyResponse represents the response from posting a comment reply.
 type ReplyResponse struct {
-	ID   int  `json:"id"`
-	User User `json:"user"`
+	ID             int    `json:"id"`
+	User           User   `json:"user"`
+	PullRequestURL string `json:"pull_request_url"`
+}
+
+// PRNumber extracts the PR number from PullRequestURL, or 0 if unknown.
+func (r *ReplyResponse) PRNumber() int {
+	return urlNumber(r.PullRequestURL)
+}
+
+// urlNumber returns the number ending an API URL such as ".../pulls/12", or 0.
+func urlNumber(url string) int {
+	i := strings.LastIndex(url, "/")
+	if i < 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(url[i+1:])
+	return n
 }
 
 // RepoInfo represents basic repository information.
@@ -79,6 +237,18 @@ type RepoInfo struct {
 	DefaultBranch string `json:"default_branch"`
 }
 
+// ParseTime parses a GitHub API timestamp. Any RFC 3339 form is accepted
+// ("Z" or "+00:00" offsets, with or without fractional seconds); unparseable
+// or empty values yield the zero time. Compare the results with After/Before,
+// never as strings.
+func ParseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 func itoa(n int) string {
 	return strconv.Itoa(n)
 }
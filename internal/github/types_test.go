@@ -0,0 +1,49 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-05-01T10:00:00Z", want},
+		{"2024-05-01T12:00:00+02:00", want},
+		{"2024-05-01T05:30:00-04:30", want},
+		{"2024-05-01T10:00:00.000Z", want},
+		{"2024-05-01T10:00:00.5Z", want.Add(500 * time.Millisecond)},
+		{"2024-05-01T12:00:00.123456+02:00", want.Add(123456 * time.Microsecond)},
+		{"", time.Time{}},
+		{"2024-05-01 10:00:00", time.Time{}},
+		{"yesterday", time.Time{}},
+	}
+	for _, tt := range tests {
+		got := ParseTime(tt.in)
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Timestamps in different zones order by instant, not by their text.
+func TestLatestTimeAcrossOffsets(t *testing.T) {
+	earlier := ReviewComment{CreatedAt: "2024-05-01T11:00:00+02:00"} // 09:00Z
+	later := ReviewComment{CreatedAt: "2024-05-01T09:30:00Z"}
+	if !later.LatestTime().After(earlier.LatestTime()) {
+		t.Errorf("%s not after %s", later.CreatedAt, earlier.CreatedAt)
+	}
+
+	edited := ReviewComment{CreatedAt: "2024-05-01T08:00:00Z", UpdatedAt: "2024-05-01T10:00:00.250Z"}
+	if got, want := edited.LatestTime(), time.Date(2024, 5, 1, 10, 0, 0, 250e6, time.UTC); !got.Equal(want) {
+		t.Errorf("LatestTime() = %v, want the edit time %v", got, want)
+	}
+
+	r := Review{SubmittedAt: "2024-05-01T12:00:00+02:00"}
+	if got := r.SubmittedTime(); !got.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("SubmittedTime() = %v", got)
+	}
+}
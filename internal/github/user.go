@@ -0,0 +1,16 @@
+package github
+
+import (
+	"context"
+
+	"auto-pr/internal/ghcli"
+)
+
+// CurrentUser returns the login gh is authenticated as.
+func CurrentUser(ctx context.Context) (string, error) {
+	var u User
+	if err := ghcli.APITyped(ctx, restPath("user"), &u); err != nil {
+		return "", err
+	}
+	return u.Login, nil
+}
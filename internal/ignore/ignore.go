@@ -0,0 +1,166 @@
+// Package ignore reads .autoprignore, the gitignore-syntax list of files
+// Claude must not modify.
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the ignore file, read from the repository root.
+const FileName = ".autoprignore"
+
+type pattern struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// Matcher matches repository-relative paths against .autoprignore patterns.
+// A nil Matcher matches nothing.
+type Matcher struct {
+	lines    []string
+	patterns []pattern
+}
+
+// Load reads .autoprignore from root. It returns nil (and no error) when the
+// file does not exist. The file protects itself.
+func Load(root string) (*Matcher, error) {
+	f, err := os.Open(filepath.Join(root, FileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &Matcher{}
+	m.add("/" + FileName)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m.add(line)
+		m.lines = append(m.lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Patterns returns the patterns as written in the file, without comments.
+func (m *Matcher) Patterns() []string {
+	if m == nil {
+		return nil
+	}
+	return m.lines
+}
+
+// Match reports whether path (relative to the repository root, with forward
+// slashes) is ignored. As in gitignore, the last matching pattern wins and
+// "!" patterns re-include paths.
+func (m *Matcher) Match(path string) bool {
+	if m == nil {
+		return false
+	}
+	ignored := false
+	for _, p := range m.patterns {
+		if p.re.MatchString(path) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Filter returns the paths that are ignored.
+func (m *Matcher) Filter(paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		if m.Match(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (m *Matcher) add(line string) {
+	p := pattern{}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	dirOnly := strings.HasSuffix(line, "/")
+	line = strings.TrimSuffix(line, "/")
+	// A slash anywhere but at the end anchors the pattern to the root;
+	// otherwise it matches at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return
+	}
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	b.WriteString(globToRegexp(line))
+	if dirOnly {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(?:/.*)?$") // a matched directory covers everything in it
+	}
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return // malformed pattern (e.g. unclosed bracket): git ignores it too
+	}
+	p.re = re
+	m.patterns = append(m.patterns, p)
+}
+
+// globToRegexp translates one gitignore glob, including "**", to a regexp.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+// Package logging provides leveled console output for the watcher.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"auto-pr/internal/redact"
+)
+
+// Level is a log severity.
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var names = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return names[l]
+}
+
+// ParseLevel parses "debug", "info", "warn" or "error" (case-insensitive).
+func ParseLevel(s string) (Level, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return Info, fmt.Errorf("invalid log level %q (expected debug, info, warn, error)", s)
+}
+
+var level = Info
+
+// SetLevel sets the minimum level that is printed.
+func SetLevel(l Level) {
+	level = l
+}
+
+// Enabled reports whether messages at l are printed.
+func Enabled(l Level) bool {
+	return l >= level
+}
+
+// Stdout returns os.Stdout if info messages are printed, io.Discard
+// otherwise. Use it for streamed output such as Claude's.
+func Stdout() io.Writer {
+	if Enabled(Info) {
+		return os.Stdout
+	}
+	return io.Discard
+}
+
+// Stderr returns os.Stderr if warnings are printed, io.Discard otherwise. Use
+// it for the error stream of streamed output.
+func Stderr() io.Writer {
+	if Enabled(Warn) {
+		return os.Stderr
+	}
+	return io.Discard
+}
+
+// Debugf prints a debug message to stdout.
+func Debugf(format string, args ...interface{}) { Logf(Debug, format, args...) }
+
+// Infof prints an info message to stdout.
+func Infof(format string, args ...interface{}) { Logf(Info, format, args...) }
+
+// Warnf prints a warning to stderr.
+func Warnf(format string, args ...interface{}) { Logf(Warn, format, args...) }
+
+// Errorf prints an error to stderr.
+func Errorf(format string, args ...interface{}) { Logf(Error, format, args...) }
+
+// Logf prints a message at level l: debug and info go to stdout, warnings
+// and errors to stderr. Tokens and secrets are masked (see redact).
+func Logf(l Level, format string, args ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+	w := os.Stdout
+	if l >= Warn {
+		w = os.Stderr
+	}
+	fmt.Fprintln(w, redact.String(fmt.Sprintf(format, args...)))
+}
+
+// Of guesses the level of a message from its wording: "Warning: ..." is a
+// warning, "Error ..." or "Failed ..." an error, anything else info.
+func Of(msg string) Level {
+	switch {
+	case strings.HasPrefix(strings.ToLower(msg), "warning"):
+		return Warn
+	case strings.HasPrefix(msg, "Error"), strings.HasPrefix(msg, "Failed"):
+		return Error
+	}
+	return Info
+}
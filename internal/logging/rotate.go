@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is an append-only log file that is rotated once it would grow
+// past a size limit: path becomes path.1, path.1 becomes path.2 and so on,
+// keeping at most the configured number of backups. It is safe for
+// concurrent use.
+type RotatingFile struct {
+	path     string
+	maxBytes int64 // 0 = never rotate
+	backups  int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenRotating opens (or creates) path for appending. With maxBytes 0 the
+// file grows without limit.
+func OpenRotating(path string, maxBytes int64, backups int) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would take the file past the limit.
+// A single write is never split across files.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups, dropping the oldest, and starts a new file. If
+// the file cannot be moved aside, writing continues at its end.
+func (r *RotatingFile) rotate() error {
+	r.f.Close()
+	if r.backups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", r.path, r.backups))
+		for i := r.backups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		}
+		os.Rename(r.path, r.path+".1")
+	} else {
+		os.Remove(r.path)
+	}
+	return r.open()
+}
+
+// Close closes the current file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
@@ -0,0 +1,48 @@
+// Package metrics keeps auto-pr's counters and gauges, registered with the
+// default Prometheus registry and served by Handler.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	IssuesScanned = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "autopr_issues_scanned_total",
+		Help: "Labeled issues seen by repo scans.",
+	})
+	WorkersSpawned = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "autopr_workers_spawned_total",
+		Help: "Issue workers started, resumed ones included.",
+	})
+	WorkersFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "autopr_workers_failed_total",
+		Help: "Issue workers that ended in the failed state.",
+	})
+	ActiveWorkers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "autopr_active_workers",
+		Help: "Issue workers currently running.",
+	})
+	ClaudeRuns = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "autopr_claude_invocations_total",
+		Help: "Claude CLI runs, local and in containers.",
+	})
+	GHCalls = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "autopr_gh_calls_total",
+		Help: "gh CLI invocations (API calls and other commands).",
+	})
+	ReviewRounds = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "autopr_review_rounds_total",
+		Help: "Review rounds handed to Claude.",
+	})
+)
+
+// Handler serves the default registry, auto-pr's metrics and the Go runtime
+// and process collectors, in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
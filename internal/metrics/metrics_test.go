@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	ReviewRounds.Inc()
+	ActiveWorkers.Inc()
+	defer ActiveWorkers.Dec()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	for _, want := range []string{
+		"# TYPE autopr_review_rounds_total counter",
+		"# HELP autopr_review_rounds_total Review rounds handed to Claude.",
+		"autopr_review_rounds_total 1",
+		"# TYPE autopr_active_workers gauge",
+		"autopr_active_workers 1",
+		"# TYPE autopr_issues_scanned_total counter",
+		"# TYPE autopr_gh_calls_total counter",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("/metrics lacks %q", want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package redact
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every redacted secret.
+const Mask = "[REDACTED]"
+
+// tokenRE matches known credential shapes: GitHub classic and fine-grained
+// tokens, and Anthropic API keys.
+var tokenRE = regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|sk-ant-[A-Za-z0-9_-]{20,})`)
+
+// sensitiveEnv lists environment variables whose values are always masked.
+var sensitiveEnv = []string{"GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "ANTHROPIC_API_KEY"}
+
+var (
+	mu    sync.Mutex
+	extra []string
+)
+
+// AddSecret masks secret in everything redacted from now on, for secrets
+// that are neither in sensitiveEnv nor shaped like a known token.
+func AddSecret(secret string) {
+	if len(secret) < 8 {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range extra {
+		if v == secret {
+			return
+		}
+	}
+	extra = append(extra, secret)
+}
+
+// String masks known token shapes and the values of sensitive environment
+// variables in s.
+func String(s string) string {
+	for _, name := range sensitiveEnv {
+		// Short values would mask unrelated text; real secrets are long.
+		if v := os.Getenv(name); len(v) >= 8 {
+			s = strings.ReplaceAll(s, v, Mask)
+		}
+	}
+	mu.Lock()
+	for _, v := range extra {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+	mu.Unlock()
+	return tokenRE.ReplaceAllString(s, Mask)
+}
+
+// Bytes is String for byte slices.
+func Bytes(b []byte) []byte {
+	return []byte(String(string(b)))
+}
+
+// maxLine bounds how much of an unterminated line a Writer holds back.
+const maxLine = 64 << 10
+
+// Writer masks secrets in everything written through it before passing it on
+// to the underlying writer. Output is passed on a line at a time, so a secret
+// split across writes is still caught; call Flush once done to pass on a last
+// unterminated line. It is safe for concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+// NewWriter returns a Writer passing redacted output on to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write buffers p and passes on every complete line, redacted.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	end := bytes.LastIndexByte(w.buf, '\n') + 1
+	if end == 0 {
+		if len(w.buf) < maxLine {
+			return len(p), nil
+		}
+		end = len(w.buf)
+	}
+	_, err := w.w.Write(Bytes(w.buf[:end]))
+	w.buf = append(w.buf[:0], w.buf[end:]...)
+	return len(p), err
+}
+
+// Flush passes on what is left of an unterminated line, redacted.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(Bytes(w.buf))
+	w.buf = w.buf[:0]
+	return err
+}
@@ -0,0 +1,157 @@
+// Package schedule parses WORK_HOURS and tells whether a time falls within
+// them.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a set of weekly time windows in a time zone.
+type Schedule struct {
+	spec    string
+	loc     *time.Location
+	windows []window
+}
+
+// window is one time range on a set of weekdays, in minutes since midnight.
+// A range whose end is not after its start runs past midnight into the
+// following day.
+type window struct {
+	days       [7]bool // indexed by time.Weekday
+	start, end int
+}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Parse parses a schedule such as "Mon-Fri 09:00-18:00" or
+// "Mon-Thu 09:00-12:00 13:00-17:00, Fri 09:00-12:00". Day ranges and names
+// (Mon, Tue-Thu) apply to the time ranges following them; time ranges with
+// no days before them apply every day. A time range like 22:00-06:00 runs
+// past midnight. tz is an IANA zone name; "" means the local time zone.
+func Parse(spec, tz string) (*Schedule, error) {
+	loc := time.Local
+	if tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", tz)
+		}
+		loc = l
+	}
+	s := &Schedule{spec: strings.TrimSpace(spec), loc: loc}
+
+	var days [7]bool
+	haveDays, afterTime := false, false
+	for _, tok := range strings.Fields(strings.ReplaceAll(spec, ",", " ")) {
+		if tok[0] >= '0' && tok[0] <= '9' {
+			start, end, err := parseTimes(tok)
+			if err != nil {
+				return nil, err
+			}
+			w := window{days: days, start: start, end: end}
+			if !haveDays {
+				w.days = [7]bool{true, true, true, true, true, true, true}
+			}
+			s.windows = append(s.windows, w)
+			afterTime = true
+			continue
+		}
+		if afterTime || !haveDays {
+			days = [7]bool{}
+		}
+		if err := parseDays(tok, &days); err != nil {
+			return nil, err
+		}
+		haveDays, afterTime = true, false
+	}
+	if len(s.windows) == 0 {
+		return nil, fmt.Errorf("no time range in %q (expected e.g. \"Mon-Fri 09:00-18:00\")", spec)
+	}
+	if !afterTime {
+		return nil, fmt.Errorf("days without a time range at the end of %q", spec)
+	}
+	return s, nil
+}
+
+// parseDays adds the days of "Mon" or "Mon-Fri" to days.
+func parseDays(tok string, days *[7]bool) error {
+	first, last, isRange := strings.Cut(strings.ToLower(tok), "-")
+	if !isRange {
+		last = first
+	}
+	from, ok1 := dayNames[first]
+	to, ok2 := dayNames[last]
+	if !ok1 || !ok2 {
+		return fmt.Errorf("invalid day %q (expected e.g. Mon or Mon-Fri)", tok)
+	}
+	for d := from; ; d = (d + 1) % 7 {
+		days[d] = true
+		if d == to {
+			return nil
+		}
+	}
+}
+
+// parseTimes parses "HH:MM-HH:MM" into minutes since midnight.
+func parseTimes(tok string) (start, end int, err error) {
+	a, b, ok := strings.Cut(tok, "-")
+	if ok {
+		start, err = parseClock(a)
+		if err == nil {
+			end, err = parseClock(b)
+		}
+	}
+	if !ok || err != nil {
+		return 0, 0, fmt.Errorf("invalid time range %q (expected e.g. 09:00-18:00)", tok)
+	}
+	if start == 24*60 {
+		return 0, 0, fmt.Errorf("invalid time range %q: 24:00 can only end a range", tok)
+	}
+	return start, end, nil
+}
+
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	if !ok || len(m) != 2 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hh < 0 || mm < 0 || mm > 59 || hh > 24 || (hh == 24 && mm != 0) {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return hh*60 + mm, nil
+}
+
+// Open reports whether t falls within the schedule. A nil schedule is always
+// open.
+func (s *Schedule) Open(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	t = t.In(s.loc)
+	day, mins := t.Weekday(), t.Hour()*60+t.Minute()
+	prev := (day + 6) % 7
+	for _, w := range s.windows {
+		if w.start < w.end {
+			if w.days[day] && mins >= w.start && mins < w.end {
+				return true
+			}
+			continue
+		}
+		if (w.days[day] && mins >= w.start) || (w.days[prev] && mins < w.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the schedule as written, with its time zone.
+func (s *Schedule) String() string {
+	return s.spec + " (" + s.loc.String() + ")"
+}
@@ -0,0 +1,164 @@
+// Package spec fetches documents referenced from issue bodies (e.g. design
+// docs linked from an issue) so they can be given to Claude as context.
+//
+// Only https URLs whose host is on an explicit allowlist are fetched, and
+// redirects must stay on the allowlist too. Fetched text is untrusted: callers
+// must delimit it clearly in prompts.
+package spec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxURLs caps how many referenced documents are fetched per issue.
+const MaxURLs = 3
+
+// Doc is a fetched document.
+type Doc struct {
+	URL       string
+	Text      string
+	Truncated bool
+}
+
+// Fetcher fetches allowlisted documents.
+type Fetcher struct {
+	Allowlist []string // allowed hosts; each also matches its subdomains
+	MaxBytes  int64
+	Timeout   time.Duration
+}
+
+// NewFetcher creates a Fetcher from a comma-separated domain allowlist.
+func NewFetcher(allowlist string, maxBytes int64, timeout time.Duration) *Fetcher {
+	var domains []string
+	for _, d := range strings.Split(allowlist, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		d = strings.TrimPrefix(d, "*.")
+		if d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return &Fetcher{Allowlist: domains, MaxBytes: maxBytes, Timeout: timeout}
+}
+
+// Enabled reports whether any domain is allowlisted.
+func (f *Fetcher) Enabled() bool {
+	return f != nil && len(f.Allowlist) > 0
+}
+
+var urlRE = regexp.MustCompile(`https://[^\s<>()\[\]"'` + "`" + `]+`)
+
+// FindURLs returns the distinct allowlisted URLs in text, in order of
+// appearance, at most MaxURLs.
+func (f *Fetcher) FindURLs(text string) []string {
+	seen := map[string]bool{}
+	var urls []string
+	for _, raw := range urlRE.FindAllString(text, -1) {
+		raw = strings.TrimRight(raw, ".,;:!?")
+		if seen[raw] || !f.Allowed(raw) {
+			continue
+		}
+		seen[raw] = true
+		urls = append(urls, raw)
+		if len(urls) == MaxURLs {
+			break
+		}
+	}
+	return urls
+}
+
+// Allowed reports whether rawURL is an https URL on an allowlisted host.
+func (f *Fetcher) Allowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, d := range f.Allowlist {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch downloads rawURL and returns its text, truncated to MaxBytes.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Doc, error) {
+	if !f.Allowed(rawURL) {
+		return nil, fmt.Errorf("%s is not on the allowlist", rawURL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.Timeout)
+	defer cancel()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if !f.Allowed(req.URL.String()) {
+				return fmt.Errorf("redirect to %s is not on the allowlist", req.URL.Host)
+			}
+			return nil
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain, text/markdown, text/html;q=0.9, application/json;q=0.8")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+
+	ctype := strings.ToLower(resp.Header.Get("Content-Type"))
+	isHTML := strings.Contains(ctype, "html")
+	if ctype != "" && !strings.HasPrefix(ctype, "text/") && !strings.Contains(ctype, "json") {
+		return nil, fmt.Errorf("GET %s: unsupported content type %q", rawURL, ctype)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	doc := &Doc{URL: rawURL}
+	if int64(len(data)) > f.MaxBytes {
+		data = data[:f.MaxBytes]
+		doc.Truncated = true
+	}
+	doc.Text = string(data)
+	if isHTML {
+		doc.Text = htmlToText(doc.Text)
+	}
+	return doc, nil
+}
+
+var (
+	scriptRE    = regexp.MustCompile(`(?is)<(script|style|noscript)\b.*?</(script|style|noscript)>`)
+	blockRE     = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6]|/tr|/pre)\b[^>]*>`)
+	tagRE       = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankRunsRE = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+)
+
+// htmlToText crudely strips markup from an HTML page, keeping line breaks
+// at block boundaries.
+func htmlToText(s string) string {
+	s = scriptRE.ReplaceAllString(s, "")
+	s = blockRE.ReplaceAllString(s, "\n")
+	s = tagRE.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = blankRunsRE.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // IssueStatus represents the lifecycle status of an issue.
@@ -16,6 +20,21 @@ const (
 	IssueWatching    IssueStatus = "watching"
 	IssueDone        IssueStatus = "done"
 	IssueFailed      IssueStatus = "failed"
+	IssueNeedsHuman  IssueStatus = "needs_human" // automation stopped (e.g. MAX_REVIEW_ROUNDS)
+	// IssueInsufficientDetail marks issues whose body is below
+	// MIN_ISSUE_BODY_CHARS; they are reconsidered once the body grows.
+	IssueInsufficientDetail IssueStatus = "insufficient_detail"
+)
+
+// Reasons recorded in IssueState.FailedReason.
+const (
+	FailedContainer  = "container_start" // the worker's Docker container did not start
+	FailedWorktree   = "worktree"        // the issue's worktree could not be created
+	FailedFetchIssue = "fetch_issue"     // the issue could not be read from GitHub
+	FailedClaude     = "claude_error"    // Claude exited with an error while implementing
+	FailedNoPR       = "no_pr"           // no PR was opened, and there were no commits to open one for
+	FailedTimeout    = "timeout"         // WORKER_TIMEOUT or REVIEW_TIMEOUT was exceeded
+	FailedWorker     = "worker_error"    // the worker stopped with any other error
 )
 
 // IssueState represents the persisted state for an issue.
@@ -24,12 +43,54 @@ type IssueState struct {
 	PID      int         `json:"pid"`
 	Branch   string      `json:"branch"`
 	PRNumber int         `json:"pr_number"`
+	Labels   []string    `json:"labels,omitempty"`
+
+	// BaseBranch is the branch the worktree was created from; PRBase is the
+	// base branch the PR targets, as last seen.
+	BaseBranch string `json:"base_branch,omitempty"`
+	PRBase     string `json:"pr_base,omitempty"`
+
+	// Claude usage accumulated across all runs for this issue.
+	TotalInputTokens  int     `json:"total_input_tokens,omitempty"`
+	TotalOutputTokens int     `json:"total_output_tokens,omitempty"`
+	TotalCostUSD      float64 `json:"total_cost_usd,omitempty"`
+
+	// ReviewRounds counts review rounds dispatched to Claude.
+	ReviewRounds int `json:"review_rounds,omitempty"`
+
+	// Session compaction: the input tokens of the last review run, the
+	// round and summary of the last compaction, and how often it happened.
+	LastInputTokens  int    `json:"last_input_tokens,omitempty"`
+	CompactedAtRound int    `json:"compacted_at_round,omitempty"`
+	CompactSummary   string `json:"compact_summary,omitempty"`
+	Compactions      int    `json:"compactions,omitempty"`
+
+	// FailedReason says why a failed issue failed (one of the Failed*
+	// constants).
+	FailedReason string `json:"failed_reason,omitempty"`
+
+	// LogGistURL links the redacted worker log uploaded on failure.
+	LogGistURL string `json:"log_gist_url,omitempty"`
+
+	// TriggerCommentID is the TRIGGER_COMMENT comment that started the work.
+	TriggerCommentID int `json:"trigger_comment_id,omitempty"`
+}
+
+func (d *Dir) issuePath(num int) string {
+	return filepath.Join(d.Root, "issues", fmt.Sprintf("%d.json", num))
 }
 
-// ReadIssue reads the state for an issue. Returns nil if not found.
+func (d *Dir) archivedIssuePath(num int) string {
+	return filepath.Join(d.Root, "issues", "archive", fmt.Sprintf("%d.json", num))
+}
+
+// ReadIssue reads the state for an issue, archived or not. Returns nil if
+// not found.
 func (d *Dir) ReadIssue(num int) *IssueState {
-	path := filepath.Join(d.Root, "issues", fmt.Sprintf("%d.json", num))
-	data, err := os.ReadFile(path)
+	data, err := os.ReadFile(d.issuePath(num))
+	if os.IsNotExist(err) {
+		data, err = os.ReadFile(d.archivedIssuePath(num))
+	}
 	if err != nil {
 		return nil
 	}
@@ -40,12 +101,131 @@ func (d *Dir) ReadIssue(num int) *IssueState {
 	return &s
 }
 
-// WriteIssue writes the state for an issue atomically.
+// WriteIssue writes the state for an issue atomically. An archived issue
+// becomes active again.
 func (d *Dir) WriteIssue(num int, s *IssueState) error {
-	path := filepath.Join(d.Root, "issues", fmt.Sprintf("%d.json", num))
+	var from IssueStatus
+	if d.OnTransition != nil {
+		if old := d.ReadIssue(num); old != nil {
+			from = old.Status
+		}
+	}
 	data, err := json.Marshal(s)
 	if err != nil {
 		return err
 	}
-	return atomicWrite(path, data)
+	if err := atomicWrite(d.issuePath(num), data); err != nil {
+		return err
+	}
+	os.Remove(d.archivedIssuePath(num))
+	if d.OnTransition != nil && s.Status != from {
+		d.OnTransition(num, from, s)
+	}
+	return nil
+}
+
+// UpdateIssue reads the state for an issue (or starts from an empty one),
+// applies fn and writes the result, preserving fields fn does not touch.
+func (d *Dir) UpdateIssue(num int, fn func(s *IssueState)) error {
+	s := d.ReadIssue(num)
+	if s == nil {
+		s = &IssueState{}
+	}
+	fn(s)
+	return d.WriteIssue(num, s)
+}
+
+// IssueModTime returns when the state for an issue, archived or not, was
+// last written.
+func (d *Dir) IssueModTime(num int) (time.Time, error) {
+	info, err := os.Stat(d.issuePath(num))
+	if os.IsNotExist(err) {
+		info, err = os.Stat(d.archivedIssuePath(num))
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// RemoveIssue deletes the state for an issue, archived or not, and its
+// worker logs, rotated ones included.
+func (d *Dir) RemoveIssue(num int) error {
+	logPath := d.LogPath(num)
+	backups, _ := filepath.Glob(logPath + ".*")
+	paths := append([]string{d.issuePath(num), d.archivedIssuePath(num), logPath}, backups...)
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListIssues returns the numbers of all issues with persisted state that is
+// not archived, ascending.
+func (d *Dir) ListIssues() ([]int, error) {
+	return d.listNums("issues")
+}
+
+// ListArchivedIssues returns the numbers of all archived issues, ascending.
+func (d *Dir) ListArchivedIssues() ([]int, error) {
+	return d.listNums(filepath.Join("issues", "archive"))
+}
+
+// Archive moves the state for an issue to issues/archive/, out of the
+// active set listed by ListIssues. ReadIssue still finds it.
+func (d *Dir) Archive(num int) error {
+	if err := os.MkdirAll(filepath.Dir(d.archivedIssuePath(num)), 0755); err != nil {
+		return err
+	}
+	return os.Rename(d.issuePath(num), d.archivedIssuePath(num))
+}
+
+// ArchiveIssues archives done and failed issues whose state was last
+// written before cutoff, and returns their numbers.
+func (d *Dir) ArchiveIssues(cutoff time.Time) ([]int, error) {
+	nums, err := d.ListIssues()
+	if err != nil {
+		return nil, err
+	}
+	var archived []int
+	for _, num := range nums {
+		s := d.ReadIssue(num)
+		if s == nil || (s.Status != IssueDone && s.Status != IssueFailed) {
+			continue
+		}
+		if mod, err := d.IssueModTime(num); err != nil || mod.After(cutoff) {
+			continue
+		}
+		if err := d.Archive(num); err != nil {
+			return archived, err
+		}
+		archived = append(archived, num)
+	}
+	return archived, nil
+}
+
+// listNums returns the numbers of the N.json files in a state subdirectory,
+// ascending.
+func (d *Dir) listNums(sub string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(d.Root, sub))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var nums []int
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(name, ".json")); err == nil {
+			nums = append(nums, n)
+		}
+	}
+	sort.Ints(nums)
+	return nums, nil
 }
@@ -5,13 +5,33 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // PRState represents the persisted state for a PR being watched.
 type PRState struct {
+	// LastCommentID and LastReviewID are the processing cursor: anything
+	// with a larger ID is new. LastCommentTS is kept for display (and to
+	// migrate state written before the cursor existed).
+	LastCommentID int    `json:"last_comment_id,omitempty"`
+	LastReviewID  int    `json:"last_review_id,omitempty"`
 	LastCommentTS string `json:"last_comment_ts"`
 	PID           int    `json:"pid"`
 	Branch        string `json:"branch"`
+	// Fingerprints holds content hashes of already-handled comments
+	// (see github.ReviewComment.Fingerprint), oldest first.
+	Fingerprints []string `json:"fingerprints,omitempty"`
+	// HandledCommentIDs and HandledReviewIDs record comments and reviews
+	// already dispatched to Claude or replied to, so they are never
+	// processed twice.
+	HandledCommentIDs []int `json:"handled_comment_ids,omitempty"`
+	HandledReviewIDs  []int `json:"handled_review_ids,omitempty"`
+	// HeadSHA is the PR head last seen on the remote, used to detect
+	// force-pushes.
+	HeadSHA string `json:"head_sha,omitempty"`
+	// LastCommandID is the last PR conversation comment checked for
+	// /auto-pr commands.
+	LastCommandID int `json:"last_command_id,omitempty"`
 }
 
 // ReadPR reads the state for a PR. Returns nil if not found.
@@ -37,3 +57,56 @@ func (d *Dir) WritePR(num int, s *PRState) error {
 	}
 	return atomicWrite(path, data)
 }
+
+// UpdatePR reads the state for a PR (or starts from an empty one), applies fn
+// and writes the result, preserving fields fn does not touch.
+func (d *Dir) UpdatePR(num int, fn func(s *PRState)) error {
+	s := d.ReadPR(num)
+	if s == nil {
+		s = &PRState{}
+	}
+	fn(s)
+	return d.WritePR(num, s)
+}
+
+// RemovePR deletes the state for a PR.
+func (d *Dir) RemovePR(num int) error {
+	err := os.Remove(filepath.Join(d.Root, "prs", fmt.Sprintf("%d.json", num)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// PRModTime returns when the state for a PR was last written.
+func (d *Dir) PRModTime(num int) (time.Time, error) {
+	info, err := os.Stat(filepath.Join(d.Root, "prs", fmt.Sprintf("%d.json", num)))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// ListPRs returns the numbers of all PRs with persisted state, ascending.
+func (d *Dir) ListPRs() ([]int, error) {
+	return d.listNums("prs")
+}
+
+// MaxHandledIDs bounds how many handled comment/review IDs are kept per PR.
+const MaxHandledIDs = 1000
+
+// MarkCommentHandled records a comment ID as handled for a PR, keeping the
+// last MaxHandledIDs.
+func (d *Dir) MarkCommentHandled(prNum int, ids ...int) error {
+	return d.UpdatePR(prNum, func(s *PRState) {
+		s.HandledCommentIDs = append(s.HandledCommentIDs, ids...)
+		if n := len(s.HandledCommentIDs); n > MaxHandledIDs {
+			s.HandledCommentIDs = s.HandledCommentIDs[n-MaxHandledIDs:]
+		}
+	})
+}
+
+// HasCursor reports whether the state carries an ID cursor.
+func (s *PRState) HasCursor() bool {
+	return s.LastCommentID > 0 || s.LastReviewID > 0
+}
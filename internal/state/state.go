@@ -12,6 +12,11 @@ import (
 // Dir manages the .pr-watch-state directory.
 type Dir struct {
 	Root string // e.g., /project/.pr-watch-state
+
+	// OnTransition, if set, is called after an issue's state is written
+	// with a status different from the one it had (from is "" for new
+	// issues).
+	OnTransition func(num int, from IssueStatus, s *IssueState)
 }
 
 // New creates a Dir for the given project root.
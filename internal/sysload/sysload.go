@@ -0,0 +1,16 @@
+// Package sysload reads the host's load average and memory, where the
+// platform exposes them.
+package sysload
+
+// Stats is a snapshot of system load.
+type Stats struct {
+	Load1    float64 // 1-minute load average
+	MemTotal uint64  // bytes
+	MemAvail uint64  // bytes available for new work
+}
+
+// Read returns the current load and memory figures. ok is false on
+// platforms where they are not available.
+func Read() (s Stats, ok bool) {
+	return read()
+}
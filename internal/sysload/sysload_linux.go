@@ -0,0 +1,50 @@
+//go:build linux
+
+package sysload
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func read() (Stats, bool) {
+	var s Stats
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return s, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return s, false
+	}
+	if s.Load1, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return s, false
+	}
+
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return s, true
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// "MemAvailable:   12345678 kB"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			s.MemTotal = kb * 1024
+		case "MemAvailable:":
+			s.MemAvail = kb * 1024
+		}
+	}
+	return s, true
+}
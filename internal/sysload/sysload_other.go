@@ -0,0 +1,7 @@
+//go:build !linux
+
+package sysload
+
+func read() (Stats, bool) {
+	return Stats{}, false
+}
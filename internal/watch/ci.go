@@ -0,0 +1,150 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"auto-pr/internal/claude"
+	"auto-pr/internal/container"
+	"auto-pr/internal/github"
+	"auto-pr/internal/ignore"
+	"auto-pr/internal/state"
+	"auto-pr/internal/worktree"
+)
+
+// maxCILogBytes caps how much of each failing job's log goes into the prompt;
+// the tail is kept since it holds the error.
+const maxCILogBytes = 20 * 1024
+
+// ciWatcher feeds failing CI on a worker's PR back to Claude. Each head
+// commit is handled at most once, and after maxAttempts consecutive failing
+// commits it gives up until CI passes again.
+type ciWatcher struct {
+	repo        string
+	prNum       int
+	issueNum    int
+	wtPath      string
+	maxAttempts int
+	trailer     string
+	ignore      *ignore.Matcher
+	format      string // FORMAT_COMMAND
+	opts        claude.Options
+	stateDir    *state.Dir
+	logFile     io.Writer
+	dockerMgr   *container.Manager
+	containerID string
+	log         func(string, ...interface{})
+
+	lastSHA  string // last head commit acted on (fixed or given up)
+	attempts int    // consecutive fix attempts
+}
+
+// check inspects the PR's head commit and, if its checks failed, asks Claude
+// to fix them.
+func (w *ciWatcher) check(ctx context.Context) {
+	pr, err := github.GetPR(ctx, w.repo, w.prNum)
+	if err != nil {
+		w.log("Warning: CI watch: %v", err)
+		return
+	}
+	sha := pr.Head.SHA
+	if sha == "" || sha == w.lastSHA {
+		return
+	}
+	runs, err := github.GetCheckRuns(ctx, w.repo, sha)
+	if err != nil {
+		w.log("Warning: CI watch: %v", err)
+		return
+	}
+
+	var failed []github.CheckRun
+	pending := false
+	for i := range runs {
+		if runs[i].Failed() {
+			failed = append(failed, runs[i])
+		} else if runs[i].Status != "completed" {
+			pending = true
+		}
+	}
+	if len(failed) == 0 {
+		if !pending {
+			w.attempts = 0 // green: a later failure gets a fresh budget
+		}
+		return
+	}
+	if pending {
+		return // wait for the whole suite so Claude sees every failure at once
+	}
+
+	w.lastSHA = sha
+	if w.attempts >= w.maxAttempts {
+		w.log("PR #%d: CI still failing after %d fix attempt(s), leaving it for a human.", w.prNum, w.attempts)
+		return
+	}
+	w.attempts++
+	w.log("PR #%d: %d CI check(s) failed on %.7s, asking Claude to fix (attempt %d/%d)",
+		w.prNum, len(failed), sha, w.attempts, w.maxAttempts)
+
+	prompt := buildCIFixPrompt(w.repo, w.prNum, pr.Head.Ref, w.failureReport(ctx, failed), w.trailer, w.ignore)
+	before := worktree.Head(w.wtPath)
+	remote := worktree.RevParse(w.wtPath, "origin/"+pr.Head.Ref)
+	w.run(ctx, prompt)
+	enforceIgnore(w.ignore, w.wtPath, pr.Head.Ref, before, remote, w.trailer, func(prompt string) { w.run(ctx, prompt) }, w.log)
+	runFormatter(ctx, w.format, w.dockerMgr, w.containerID, w.wtPath, pr.Head.Ref, before, w.trailer, w.logFile, w.log)
+}
+
+func (w *ciWatcher) run(ctx context.Context, prompt string) {
+	res, err := runClaudeContinue(ctx, w.dockerMgr, w.containerID, w.wtPath, prompt, w.opts, w.logFile)
+	recordUsage(w.stateDir, w.issueNum, res, w.log)
+	if err != nil {
+		w.log("Warning: claude exited with error during CI fix: %v", err)
+	}
+}
+
+// retry forgets earlier attempts and re-checks the head commit, so failing
+// CI gets fixed even if it was already given up on (or WATCH_CI is off).
+func (w *ciWatcher) retry(ctx context.Context) {
+	w.lastSHA, w.attempts = "", 0
+	max := w.maxAttempts
+	if w.maxAttempts < 1 {
+		w.maxAttempts = 1
+	}
+	w.check(ctx)
+	w.maxAttempts = max
+}
+
+// failureReport describes each failed run, with the log tail for GitHub
+// Actions jobs.
+func (w *ciWatcher) failureReport(ctx context.Context, failed []github.CheckRun) string {
+	var b strings.Builder
+	for _, r := range failed {
+		fmt.Fprintf(&b, "### %s (%s)\n%s\n", r.Name, r.Conclusion, r.HTMLURL)
+		if r.App.Slug != "github-actions" {
+			b.WriteString("(log not available through the API; see the link above)\n\n")
+			continue
+		}
+		logText, err := github.GetJobLog(ctx, w.repo, r.ID, maxCILogBytes)
+		if err != nil {
+			w.log("Warning: %v", err)
+			b.WriteString("(log could not be fetched)\n\n")
+			continue
+		}
+		fmt.Fprintf(&b, "```\n%s\n```\n\n", strings.TrimSpace(logText))
+	}
+	return b.String()
+}
+
+func buildCIFixPrompt(repo string, prNum int, branch, report, trailer string, ign *ignore.Matcher) string {
+	return fmt.Sprintf(`CI failed on PR #%d (branch: %s) in repo %s. Here is the output of the failing checks (log tails):
+
+%s
+Your task:
+1. Find the cause of each failure from the output above
+2. Fix it in the code you wrote for this PR — if the failure is unrelated to this PR (flaky test, infrastructure), do not change code; explain why in a PR comment with: gh pr comment %d --body "..."
+3. Commit and push with a single commit%s
+
+Constraints: Only modify files related to the failure. Do not touch CLAUDE.md, .claude/, scripts/, .gitignore, CI configs.%s`,
+		prNum, branch, repo, report, prNum, trailerInstruction(trailer), ignoreConstraint(ign))
+}
@@ -0,0 +1,178 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"auto-pr/internal/github"
+	"auto-pr/internal/state"
+	"auto-pr/internal/worktree"
+)
+
+// commandPrefix starts a slash-command in a PR conversation comment.
+const commandPrefix = "/auto-pr"
+
+// prCommand is an action maintainers can trigger from the PR conversation.
+// run returns stop=true when the worker should stop watching the PR.
+type prCommand struct {
+	help string
+	run  func(r *commandRunner, ctx context.Context, base string) (stop bool, err error)
+}
+
+// prCommands is the command registry ("/auto-pr help" is handled separately).
+var prCommands = map[string]prCommand{
+	"rebase": {"rebase the branch onto the PR's base branch and force-push", (*commandRunner).rebase},
+	"fix-ci": {"ask Claude to fix the failing CI checks now, with a fresh attempt budget", (*commandRunner).fixCI},
+	"pause":  {"stop watching this PR and leave it to humans", (*commandRunner).pause},
+}
+
+// commandRunner executes slash-commands posted on a worker's PR by trusted
+// authors (see trustedAuthor). Each command gets a reaction: +1 when done,
+// -1 when it failed, confused when unknown.
+type commandRunner struct {
+	repo     string
+	prNum    int
+	issueNum int
+	wtPath   string
+	branch   string
+	users    string // COMMAND_USERS
+	ci       *ciWatcher
+	stateDir *state.Dir
+	log      func(string, ...interface{})
+
+	lastID int // last conversation comment looked at
+}
+
+// baseline starts the runner after the comments already on the PR, unless a
+// previous run recorded where it left off.
+func (r *commandRunner) baseline(ctx context.Context) {
+	if s := r.stateDir.ReadPR(r.prNum); s != nil && s.LastCommandID > 0 {
+		r.lastID = s.LastCommandID
+		return
+	}
+	comments, err := github.FetchIssueComments(ctx, r.repo, r.prNum)
+	if err != nil {
+		r.log("Warning: PR commands: %v", err)
+		return
+	}
+	for _, c := range comments {
+		if c.ID > r.lastID {
+			r.lastID = c.ID
+		}
+	}
+	r.save()
+}
+
+// poll runs the commands posted since the last poll, in order. base is the
+// PR's current base branch. Returns true when the worker should stop.
+func (r *commandRunner) poll(ctx context.Context, base string) bool {
+	comments, err := github.FetchIssueComments(ctx, r.repo, r.prNum)
+	if err != nil {
+		r.log("Warning: PR commands: %v", err)
+		return false
+	}
+	for i := range comments {
+		c := &comments[i]
+		if c.ID <= r.lastID {
+			continue
+		}
+		r.lastID = c.ID
+		r.save()
+
+		name, ok := parseCommand(c.Body)
+		if !ok || !trustedAuthor(c, r.users) {
+			continue
+		}
+		r.log("PR #%d: @%s issued /auto-pr %s", r.prNum, c.User.Login, name)
+		if name == "help" {
+			r.react(ctx, c.ID, outcome(r.reply(ctx, commandHelp())))
+			continue
+		}
+		cmd, known := prCommands[name]
+		if !known {
+			r.reply(ctx, fmt.Sprintf("Unknown command `%s %s`.\n\n%s", commandPrefix, name, commandHelp()))
+			r.react(ctx, c.ID, "confused")
+			continue
+		}
+		stop, err := cmd.run(r, ctx, base)
+		if err != nil {
+			r.log("Warning: /auto-pr %s failed: %v", name, err)
+		}
+		r.react(ctx, c.ID, outcome(err))
+		if stop {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *commandRunner) rebase(ctx context.Context, base string) (bool, error) {
+	r.log("Rebasing branch onto '%s' on request...", base)
+	return false, worktree.Rebase(r.wtPath, r.branch, base, base)
+}
+
+func (r *commandRunner) fixCI(ctx context.Context, base string) (bool, error) {
+	r.ci.retry(ctx)
+	return false, nil
+}
+
+func (r *commandRunner) pause(ctx context.Context, base string) (bool, error) {
+	r.log("PR #%d paused on request, handing over to a human.", r.prNum)
+	r.stateDir.UpdateIssue(r.issueNum, func(s *state.IssueState) { s.Status = state.IssueNeedsHuman })
+	return true, nil
+}
+
+// reply posts body on the PR conversation.
+func (r *commandRunner) reply(ctx context.Context, body string) error {
+	return github.CommentOnIssue(ctx, r.repo, r.prNum, body)
+}
+
+// react acknowledges a command comment with a reaction.
+func (r *commandRunner) react(ctx context.Context, commentID int, content string) {
+	if err := github.ReactToIssueComment(ctx, r.repo, commentID, content); err != nil {
+		r.log("Warning: could not acknowledge command: %v", err)
+	}
+}
+
+// outcome is the reaction for a command that returned err.
+func outcome(err error) string {
+	if err != nil {
+		return "-1"
+	}
+	return "+1"
+}
+
+func (r *commandRunner) save() {
+	r.stateDir.UpdatePR(r.prNum, func(s *state.PRState) { s.LastCommandID = r.lastID })
+}
+
+// parseCommand extracts the command name from a comment whose first line is
+// "/auto-pr <name>". A bare "/auto-pr" means help.
+func parseCommand(body string) (string, bool) {
+	fields := strings.Fields(firstLine(strings.TrimSpace(body)))
+	if len(fields) == 0 || fields[0] != commandPrefix {
+		return "", false
+	}
+	if len(fields) == 1 {
+		return "help", true
+	}
+	return strings.ToLower(fields[1]), true
+}
+
+// commandHelp lists the available commands.
+func commandHelp() string {
+	names := make([]string, 0, len(prCommands))
+	for name := range prCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString("Available commands:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "- `%s %s`: %s\n", commandPrefix, name, prCommands[name].help)
+	}
+	fmt.Fprintf(&b, "- `%s help`: show this list\n", commandPrefix)
+	return b.String()
+}
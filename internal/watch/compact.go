@@ -0,0 +1,61 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"auto-pr/internal/container"
+	"auto-pr/internal/state"
+)
+
+const compactPrompt = `This session is about to be replaced by a fresh one to keep its context small. Write a handover summary for the new session, which will keep handling review feedback on this PR. Cover:
+- what the issue asked for and how it was implemented (files, key functions, design decisions)
+- the review feedback handled so far and what was changed for it
+- open questions, things reviewers asked to keep as they are, and anything still pending
+
+Do not change any files. Reply with the summary only.`
+
+// needsCompaction reports whether the worker's Claude session should be
+// compacted before the next review round: COMPACT_AFTER_ROUNDS rounds since
+// the last compaction, or a last run above COMPACT_INPUT_TOKENS.
+func needsCompaction(cfg WorkerConfig, s *state.IssueState) bool {
+	if s == nil {
+		return false
+	}
+	if cfg.CompactAfterRounds > 0 && s.ReviewRounds-s.CompactedAtRound >= cfg.CompactAfterRounds {
+		return true
+	}
+	return cfg.CompactInputTokens > 0 && s.LastInputTokens >= cfg.CompactInputTokens
+}
+
+// compactSession asks the current session for a handover summary and stores
+// it in the issue state. It returns "" if no summary could be obtained, in
+// which case the session simply continues.
+func compactSession(ctx context.Context, dockerMgr *container.Manager, containerID, wtPath string, issueNum int, cfg WorkerConfig, stateDir *state.Dir, logFile io.Writer, log func(string, ...interface{})) string {
+	s := stateDir.ReadIssue(issueNum)
+	log("Compacting Claude session (%d review round(s) since the last compaction, last run %d input tokens)...",
+		s.ReviewRounds-s.CompactedAtRound, s.LastInputTokens)
+	res, err := runClaudeContinue(ctx, dockerMgr, containerID, wtPath, compactPrompt, cfg.claudeOptions(phaseReview), logFile)
+	recordUsage(stateDir, issueNum, res, log)
+	summary := strings.TrimSpace(res.Text)
+	if err != nil || summary == "" {
+		log("Warning: session compaction failed, continuing the current session: %v", err)
+		return ""
+	}
+	stateDir.UpdateIssue(issueNum, func(s *state.IssueState) {
+		s.CompactSummary = summary
+		s.CompactedAtRound = s.ReviewRounds
+		s.LastInputTokens = 0
+		s.Compactions++
+	})
+	log("Session compacted (%d characters of summary); the next round starts a fresh session.", len(summary))
+	return summary
+}
+
+// compactedPreamble opens the first prompt of a fresh session with the
+// summary of the one it replaces.
+func compactedPreamble(summary string) string {
+	return fmt.Sprintf("You are taking over an auto-pr worker session that was compacted to save context. Summary of the previous session:\n\n%s\n\n---\n\n", summary)
+}
@@ -0,0 +1,45 @@
+package watch
+
+import (
+	"runtime"
+
+	"auto-pr/internal/sysload"
+)
+
+// Adaptive concurrency thresholds: below lowLoad (load average per CPU) the
+// full MAX_CONCURRENT is allowed, at highLoad or above only MIN_CONCURRENT,
+// linearly in between. Less than minMemAvail of memory available also
+// drops to MIN_CONCURRENT.
+const (
+	lowLoad     = 0.5
+	highLoad    = 1.0
+	minMemAvail = 0.10
+)
+
+// concurrencyBudget returns how many workers may run at once. Without
+// ADAPTIVE_CONCURRENCY, or where load is not available, it is maxConcurrent.
+func concurrencyBudget(cfg WorkerConfig, maxConcurrent int) (budget int, stats sysload.Stats) {
+	if !cfg.AdaptiveConcurrency {
+		return maxConcurrent, stats
+	}
+	stats, ok := sysload.Read()
+	if !ok {
+		return maxConcurrent, stats
+	}
+	return budgetFor(stats, runtime.NumCPU(), min(cfg.MinConcurrent, maxConcurrent), maxConcurrent), stats
+}
+
+func budgetFor(s sysload.Stats, cpus, lo, hi int) int {
+	if s.MemTotal > 0 && float64(s.MemAvail) < minMemAvail*float64(s.MemTotal) {
+		return lo
+	}
+	perCPU := s.Load1 / float64(max(cpus, 1))
+	switch {
+	case perCPU <= lowLoad:
+		return hi
+	case perCPU >= highLoad:
+		return lo
+	}
+	frac := (highLoad - perCPU) / (highLoad - lowLoad)
+	return lo + int(frac*float64(hi-lo))
+}
@@ -1,10 +1,160 @@
 package watch
 
+import (
+	"auto-pr/internal/claude"
+	"auto-pr/internal/ignore"
+	"auto-pr/internal/schedule"
+	"auto-pr/internal/spec"
+)
+
 // WorkerConfig holds configuration for worker goroutines.
 type WorkerConfig struct {
-	WorktreeDir   string
-	BaseBranch    string
-	IssueLabels   string
-	DockerEnabled bool
-	DockerImage   string
+	WorktreeDir string
+	BaseBranch  string
+	IssueLabels string
+	// IssueExcludeLabels parks issues that also carry one of these labels.
+	IssueExcludeLabels string
+	// PriorityLabels orders issues when slots are scarce (earlier = first).
+	PriorityLabels string
+	DockerEnabled  bool
+	DockerImage    string
+	CommitTrailer  string
+	DedupComments  bool
+	// UploadLogOnFailure uploads a failed worker's redacted log to a secret gist.
+	UploadLogOnFailure bool
+	// DockerStartTimeout bounds container start, in seconds (0 = no limit).
+	DockerStartTimeout int
+	// DockerFallbackLocal runs Claude on the host if the container fails to start.
+	DockerFallbackLocal bool
+	// AutoApplySuggestions commits reviewers' suggestion blocks directly.
+	AutoApplySuggestions bool
+	// AutoMerge is the merge method for approved, green PRs ("" = off).
+	AutoMerge string
+	// SpecFetcher fetches allowlisted docs linked from issue bodies (nil = off).
+	SpecFetcher *spec.Fetcher
+	// WatchCI feeds failing CI checks back to Claude, at most CIFixAttempts
+	// times in a row per PR.
+	WatchCI       bool
+	CIFixAttempts int
+	// AutoRebase keeps the PR rebased onto its base, letting Claude resolve
+	// conflicts at most AutoRebaseAttempts times in a row.
+	AutoRebase         bool
+	AutoRebaseAttempts int
+	// BaseMismatch is "warn" or "rebase" (see checkBase).
+	BaseMismatch string
+	// ConflictAction is ConflictPrompt or ConflictPause.
+	ConflictAction string
+	// ClaudeVerbose selects the phases that run claude with --verbose:
+	// "all" (or ""), "implement", "review" or "none".
+	ClaudeVerbose string
+	// ClaudeAllowedTools / ClaudePermissionMode are passed to every claude
+	// run ("" = claude's defaults).
+	ClaudeAllowedTools   string
+	ClaudePermissionMode string
+	// ClaudeExtraArgs are passed to every claude run before its own flags.
+	ClaudeExtraArgs []string
+	// ClaudeAppendSystemPrompt is added to Claude's system prompt in every
+	// run ("" = none).
+	ClaudeAppendSystemPrompt string
+	// ClaudeMCPConfig is the absolute host path of the MCP config passed to
+	// every claude run ("" = none).
+	ClaudeMCPConfig string
+	// TriggerComment, when set, holds labeled issues back until a trusted
+	// user (TriggerUsers, or repo owners/members/collaborators) posts it.
+	TriggerComment string
+	TriggerUsers   string
+	// BotLogin is the login whose comments and reviews are ignored, so the
+	// watcher never reacts to its own replies ("" = ignore nobody).
+	BotLogin string
+	// MaxReviewRounds caps the review rounds handed to Claude per PR;
+	// past it the worker asks for a human and stops (0 = no limit).
+	MaxReviewRounds int
+	// PRCommands enables /auto-pr commands in worker PR conversations from
+	// CommandUsers (or repo owners/members/collaborators).
+	PRCommands   bool
+	CommandUsers string
+	// MaxCommentsPerRound splits review rounds with more inline comments
+	// into several Claude runs (0 = no limit).
+	MaxCommentsPerRound int
+	// MinIssueBodyChars skips issues with shorter bodies until they are
+	// edited (0 = off); InsufficientDetailComment is posted on them.
+	MinIssueBodyChars         int
+	InsufficientDetailComment string
+	// AdaptiveConcurrency scales the number of running workers between
+	// MinConcurrent and the max-concurrent setting with system load.
+	AdaptiveConcurrency bool
+	MinConcurrent       int
+	// CompactAfterRounds / CompactInputTokens replace a worker's Claude
+	// session with a fresh one seeded by a summary every N review rounds or
+	// once a run's input tokens exceed the threshold (0 = off).
+	CompactAfterRounds int
+	CompactInputTokens int
+	// Ignore holds the .autoprignore patterns Claude must not touch (nil = none).
+	Ignore *ignore.Matcher
+	// EditScope is ScopeOff, ScopeRevert or ScopeComment.
+	EditScope string
+	// FormatCommand runs on the files Claude changed after each run ("" = off).
+	FormatCommand string
+	// IssueProgressComments comments on the issue when work starts and when
+	// the PR is opened.
+	IssueProgressComments bool
+	// Health records scan progress for --health-addr (nil = off).
+	Health *Health
+	// ShutdownTimeout bounds how long shutdown waits for workers, in seconds
+	// (0 = no limit).
+	ShutdownTimeout int
+	// MaxIssuesPerScan caps the new issues started per scan (0 = no limit).
+	MaxIssuesPerScan int
+	// WorkerTimeout and ReviewTimeout bound the implementation and review
+	// phases of a worker, in seconds (0 = no limit).
+	WorkerTimeout int
+	ReviewTimeout int
+	// MaxPromptBodyBytes trims issue bodies and review data for prompts
+	// (0 = no limit; see fitComments).
+	MaxPromptBodyBytes int
+	// PRDraft has Claude open draft PRs, marked ready once checks pass.
+	PRDraft bool
+	// PRReviewers are asked to review each PR a worker opens (see
+	// requestReviewers).
+	PRReviewers string
+	// PRLabels are added to each PR a worker opens, PRDoneLabel when the
+	// worker is done with it (comma-separated).
+	PRLabels    string
+	PRDoneLabel string
+	// CloseIssueOnMerge closes the issue once its PR is merged.
+	CloseIssueOnMerge bool
+	// LogMaxSizeMB rotates worker logs at this size (0 = never), keeping
+	// LogMaxBackups rotated files.
+	LogMaxSizeMB  int
+	LogMaxBackups int
+	// ArchiveAfter is the age in seconds at which the Repo loop archives
+	// done and failed issue state (0 = never).
+	ArchiveAfter int
+	// WorkHours is when new issues are started (nil = always). With
+	// WorkHoursPauseReviews, workers also hold review handling outside it.
+	WorkHours             *schedule.Schedule
+	WorkHoursPauseReviews bool
+	// Prompts are the repo's prompt templates (nil = built-in prompts).
+	Prompts *Prompts
+	// OnceFull makes --once workers continue into the review phase instead
+	// of stopping once their PR is open.
+	OnceFull bool
+}
+
+// Claude phases, for per-phase options.
+const (
+	phaseImplement = "implement" // Phase 1: implement the issue
+	phaseReview    = "review"    // review and CI handling, and single-PR mode
+)
+
+// claudeOptions returns the claude options for a phase.
+func (c WorkerConfig) claudeOptions(phase string) claude.Options {
+	return claude.Options{
+		Verbose:            c.ClaudeVerbose == "" || c.ClaudeVerbose == "all" || c.ClaudeVerbose == phase,
+		AllowedTools:       c.ClaudeAllowedTools,
+		PermissionMode:     c.ClaudePermissionMode,
+		ExtraArgs:          c.ClaudeExtraArgs,
+		MCPConfig:          c.ClaudeMCPConfig,
+		AppendSystemPrompt: c.ClaudeAppendSystemPrompt,
+	}
 }
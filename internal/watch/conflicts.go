@@ -0,0 +1,176 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"auto-pr/internal/github"
+	"auto-pr/internal/state"
+)
+
+// Conflict actions (CONFLICT_ACTION).
+const (
+	ConflictPrompt = "prompt" // flag the conflict to Claude, which reconciles or asks
+	ConflictPause  = "pause"  // skip the conflicting comments and ask reviewers to align
+)
+
+// conflict is a set of overlapping inline comments from different reviewers.
+type conflict struct {
+	Path     string
+	Side     string // "RIGHT" (new code) or "LEFT" (removed code)
+	Start    int
+	End      int
+	Comments []github.ReviewComment
+}
+
+// findConflicts clusters comments on the same file and side of the diff whose
+// line ranges overlap and reports the clusters that involve more than one
+// reviewer. Outdated comments, which only have lines of an earlier version of
+// the file, are left out: their lines cannot be compared with current ones.
+func findConflicts(comments []github.ReviewComment) []conflict {
+	type ranged struct {
+		c          github.ReviewComment
+		side       string
+		start, end int
+	}
+	var items []ranged
+	for _, c := range comments {
+		if c.Line == nil {
+			continue
+		}
+		if start, end, ok := c.Span(); ok {
+			items = append(items, ranged{c, sideOf(&c), start, end})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].c.Path != items[j].c.Path {
+			return items[i].c.Path < items[j].c.Path
+		}
+		if items[i].side != items[j].side {
+			return items[i].side < items[j].side
+		}
+		return items[i].start < items[j].start
+	})
+
+	var out []conflict
+	var cur *conflict
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		users := map[string]bool{}
+		for _, c := range cur.Comments {
+			users[c.User.Login] = true
+		}
+		if len(users) > 1 {
+			out = append(out, *cur)
+		}
+		cur = nil
+	}
+	for _, it := range items {
+		if cur != nil && it.c.Path == cur.Path && it.side == cur.Side && it.start <= cur.End {
+			cur.Comments = append(cur.Comments, it.c)
+			if it.end > cur.End {
+				cur.End = it.end
+			}
+			continue
+		}
+		flush()
+		cur = &conflict{Path: it.c.Path, Side: it.side, Start: it.start, End: it.end, Comments: []github.ReviewComment{it.c}}
+	}
+	flush()
+	return out
+}
+
+// sideOf returns the side of the diff c is on; comments without one are on
+// the new code.
+func sideOf(c *github.ReviewComment) string {
+	if c.Side == "LEFT" {
+		return "LEFT"
+	}
+	return "RIGHT"
+}
+
+func (c *conflict) lines() string {
+	lines := fmt.Sprint(c.Start)
+	if c.Start != c.End {
+		lines = fmt.Sprintf("%d-%d", c.Start, c.End)
+	}
+	if c.Side == "LEFT" {
+		lines += " (removed code)"
+	}
+	return lines
+}
+
+func (c *conflict) reviewers() string {
+	seen := map[string]bool{}
+	var names []string
+	for _, cm := range c.Comments {
+		if !seen[cm.User.Login] {
+			seen[cm.User.Login] = true
+			names = append(names, "@"+cm.User.Login)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// handleConflicts detects overlapping feedback from different reviewers.
+// With ConflictPrompt it returns a prompt note flagging each conflict. With
+// ConflictPause it posts a PR comment asking the reviewers to align, marks the
+// conflicting comments handled and returns the rest (nil if nothing is left).
+func handleConflicts(ctx context.Context, repo string, prNum int, data *github.NewComments, action string, stateDir *state.Dir, fingerprints bool, log func(string, ...interface{})) (*github.NewComments, string) {
+	conflicts := findConflicts(data.InlineComments)
+	if len(conflicts) == 0 {
+		return data, ""
+	}
+	for _, c := range conflicts {
+		log("Conflicting feedback on %s:%s from %s", c.Path, c.lines(), c.reviewers())
+	}
+
+	if action != ConflictPause {
+		return data, conflictNote(conflicts)
+	}
+
+	var body strings.Builder
+	body.WriteString("I found review comments that may contradict each other, so I'm holding off on these until the reviewers agree:\n")
+	skip := map[int]bool{}
+	var paused []github.ReviewComment
+	for _, c := range conflicts {
+		fmt.Fprintf(&body, "\n- `%s` line %s — %s:\n", c.Path, c.lines(), c.reviewers())
+		for _, cm := range c.Comments {
+			fmt.Fprintf(&body, "  - %s (@%s)\n", cm.HTMLURL, cm.User.Login)
+			skip[cm.ID] = true
+			paused = append(paused, cm)
+		}
+	}
+	body.WriteString("\nPlease reply with the agreed direction and I'll pick it up.")
+	if err := github.CommentOnIssue(ctx, repo, prNum, body.String()); err != nil {
+		log("Warning: could not post conflict comment: %v", err)
+		return data, conflictNote(conflicts) // couldn't ask, so let Claude handle it
+	}
+	log("Paused %d conflicting comment(s) and asked reviewers to align.", len(paused))
+	recordHandled(stateDir, prNum, &github.NewComments{InlineComments: paused}, fingerprints)
+	return data.Without(func(c *github.ReviewComment) bool { return skip[c.ID] }, nil), ""
+}
+
+// conflictNote tells Claude which comments conflict and how to treat them.
+func conflictNote(conflicts []conflict) string {
+	var b strings.Builder
+	b.WriteString(`
+
+【Conflicting feedback】
+Some comments below come from different reviewers on overlapping lines and may contradict each other:`)
+	for _, c := range conflicts {
+		var ids []string
+		for _, cm := range c.Comments {
+			ids = append(ids, fmt.Sprintf("%d (@%s)", cm.ID, cm.User.Login))
+		}
+		fmt.Fprintf(&b, "\n- %s line %s: comments %s", c.Path, c.lines(), strings.Join(ids, ", "))
+	}
+	b.WriteString(`
+For each group: if the comments are compatible, satisfy all of them. If they genuinely contradict, do NOT pick one arbitrarily —
+leave that code unchanged and reply to each comment with ./scripts/pr-reply asking the reviewers to agree on one direction.`)
+	return b.String()
+}
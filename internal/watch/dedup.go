@@ -0,0 +1,109 @@
+package watch
+
+import (
+	"time"
+
+	"auto-pr/internal/github"
+	"auto-pr/internal/state"
+)
+
+// maxFingerprints bounds how many handled-comment hashes are kept per PR.
+const maxFingerprints = 500
+
+// dropHandled removes comments and reviews whose IDs are recorded as already
+// handled on this PR. Returns nil if nothing new remains.
+func dropHandled(stateDir *state.Dir, prNum int, data *github.NewComments) *github.NewComments {
+	prState := stateDir.ReadPR(prNum)
+	if prState == nil || (len(prState.HandledCommentIDs) == 0 && len(prState.HandledReviewIDs) == 0) {
+		return data
+	}
+	comments := toSet(prState.HandledCommentIDs)
+	reviews := toSet(prState.HandledReviewIDs)
+	return data.Without(
+		func(c *github.ReviewComment) bool { return comments[c.ID] },
+		func(r *github.Review) bool { return reviews[r.ID] },
+	)
+}
+
+// dropDuplicates removes comments and reviews whose content fingerprint was
+// already handled on this PR. Returns nil if nothing new remains.
+func dropDuplicates(stateDir *state.Dir, prNum int, data *github.NewComments) *github.NewComments {
+	prState := stateDir.ReadPR(prNum)
+	if prState == nil || len(prState.Fingerprints) == 0 {
+		return data
+	}
+	seen := make(map[string]bool, len(prState.Fingerprints))
+	for _, fp := range prState.Fingerprints {
+		seen[fp] = true
+	}
+	return data.Without(
+		func(c *github.ReviewComment) bool { return seen[c.Fingerprint()] },
+		func(r *github.Review) bool { return seen[r.Fingerprint()] },
+	)
+}
+
+// recordHandled stores the IDs of data in the PR state so the same comments
+// are never dispatched twice, and with fingerprints also their content hashes
+// so identical comments in later rounds are skipped.
+func recordHandled(stateDir *state.Dir, prNum int, data *github.NewComments, fingerprints bool) {
+	stateDir.UpdatePR(prNum, func(s *state.PRState) {
+		for i := range data.InlineComments {
+			c := &data.InlineComments[i]
+			s.HandledCommentIDs = append(s.HandledCommentIDs, c.ID)
+			if fingerprints {
+				s.Fingerprints = append(s.Fingerprints, c.Fingerprint())
+			}
+		}
+		for i := range data.TopLevelReviews {
+			r := &data.TopLevelReviews[i]
+			s.HandledReviewIDs = append(s.HandledReviewIDs, r.ID)
+			if fingerprints {
+				s.Fingerprints = append(s.Fingerprints, r.Fingerprint())
+			}
+		}
+		s.HandledCommentIDs = keepLast(s.HandledCommentIDs, state.MaxHandledIDs)
+		s.HandledReviewIDs = keepLast(s.HandledReviewIDs, state.MaxHandledIDs)
+		if n := len(s.Fingerprints); n > maxFingerprints {
+			s.Fingerprints = s.Fingerprints[n-maxFingerprints:]
+		}
+	})
+}
+
+func toSet(ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func keepLast(ids []int, n int) []int {
+	if len(ids) > n {
+		return ids[len(ids)-n:]
+	}
+	return ids
+}
+
+// cursorOf returns the processing cursor stored in a PR state.
+func cursorOf(s *state.PRState) github.Cursor {
+	return github.Cursor{CommentID: s.LastCommentID, ReviewID: s.LastReviewID, Timestamp: github.ParseTime(s.LastCommentTS)}
+}
+
+// saveCursor persists the processing cursor for a PR.
+func saveCursor(stateDir *state.Dir, prNum int, cur github.Cursor) {
+	stateDir.UpdatePR(prNum, func(s *state.PRState) {
+		s.LastCommentID = cur.CommentID
+		s.LastReviewID = cur.ReviewID
+		if !cur.Timestamp.IsZero() {
+			s.LastCommentTS = cur.Timestamp.UTC().Format(time.RFC3339)
+		}
+	})
+}
+
+// displayTS formats a cursor timestamp for logs.
+func displayTS(t time.Time) string {
+	if t.IsZero() {
+		return "none"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,60 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"auto-pr/internal/state"
+)
+
+// eventTimeout bounds each ON_EVENT_COMMAND run.
+const eventTimeout = 30 * time.Second
+
+// eventNames maps issue statuses to the AUTOPR_EVENT of the transition into
+// them.
+var eventNames = map[state.IssueStatus]string{
+	state.IssuePreexisting:        "issue_preexisting",
+	state.IssueInProgress:         "worker_started",
+	state.IssueWatching:           "pr_opened",
+	state.IssueDone:               "worker_done",
+	state.IssueFailed:             "worker_failed",
+	state.IssueNeedsHuman:         "needs_human",
+	state.IssueInsufficientDetail: "insufficient_detail",
+}
+
+// EventHook returns a state.Dir OnTransition hook that runs command through
+// sh for every issue status change, with the details in AUTOPR_* environment
+// variables. Runs are detached and bounded by eventTimeout; failures are only
+// logged. Returns nil when command is empty.
+func EventHook(command, repo string) func(int, state.IssueStatus, *state.IssueState) {
+	if command == "" {
+		return nil
+	}
+	return func(num int, from state.IssueStatus, s *state.IssueState) {
+		event, ok := eventNames[s.Status]
+		if !ok {
+			event = string(s.Status)
+		}
+		env := append(os.Environ(),
+			"AUTOPR_EVENT="+event,
+			"AUTOPR_REPO="+repo,
+			fmt.Sprintf("AUTOPR_ISSUE=%d", num),
+			fmt.Sprintf("AUTOPR_PR=%d", s.PRNumber),
+			"AUTOPR_BRANCH="+s.Branch,
+			"AUTOPR_STATUS="+string(s.Status),
+			"AUTOPR_PREV_STATUS="+string(from),
+		)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), eventTimeout)
+			defer cancel()
+			cmd := exec.CommandContext(ctx, "sh", "-c", command)
+			cmd.Env = env
+			if out, err := cmd.CombinedOutput(); err != nil {
+				warnf("Warning: ON_EVENT_COMMAND (%s, issue #%d) failed: %v %s", event, num, err, out)
+			}
+		}()
+	}
+}
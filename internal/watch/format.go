@@ -0,0 +1,85 @@
+package watch
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"auto-pr/internal/container"
+	"auto-pr/internal/redact"
+	"auto-pr/internal/worktree"
+)
+
+// formatCommit is the message of the commit holding FORMAT_COMMAND's changes.
+const formatCommit = "Apply FORMAT_COMMAND"
+
+// runFormatter runs FORMAT_COMMAND on the files Claude changed in dir since
+// commit before, in the worker's container when it has one and on the host
+// otherwise, then commits and pushes whatever the formatter changed to
+// branch. The file names are appended to the command. A failing formatter is
+// logged and otherwise ignored.
+func runFormatter(ctx context.Context, command string, dockerMgr *container.Manager, containerID, dir, branch, before, trailer string, logWriter io.Writer, log func(string, ...interface{})) {
+	if command == "" || before == "" {
+		return
+	}
+	changed, err := worktree.ChangedSince(dir, before)
+	if err != nil {
+		log("Warning: FORMAT_COMMAND: %v", err)
+		return
+	}
+	var files []string
+	for _, f := range changed {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			files = append(files, f) // deleted files have nothing to format
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+	dirty := map[string]bool{}
+	if pending, err := worktree.ChangedSince(dir, "HEAD"); err == nil {
+		for _, f := range pending {
+			dirty[f] = true
+		}
+	}
+
+	log("Running FORMAT_COMMAND on %d file(s)...", len(files))
+	args := append([]string{"sh", "-c", command + ` "$@"`, "sh"}, files...)
+	if dockerMgr != nil && containerID != "" {
+		err = dockerMgr.Exec(ctx, containerID, toContainerPath(dir, dockerMgr.ProjectRoot), args, logWriter)
+	} else {
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = dir
+		out := redact.NewWriter(logWriter)
+		cmd.Stdout, cmd.Stderr = out, out
+		err = cmd.Run()
+		out.Flush()
+	}
+	if err != nil {
+		log("Warning: FORMAT_COMMAND failed: %v", err)
+	}
+
+	// Only commit what the formatter changed, not changes Claude left behind.
+	pending, err := worktree.ChangedSince(dir, "HEAD")
+	if err != nil {
+		log("Warning: FORMAT_COMMAND: %v", err)
+		return
+	}
+	var formatted []string
+	for _, f := range pending {
+		if !dirty[f] {
+			formatted = append(formatted, f)
+		}
+	}
+	if len(formatted) == 0 {
+		return
+	}
+	if err := worktree.CommitAndPush(dir, branch, formatCommit, trailer, formatted); err != nil {
+		log("Warning: could not push formatting changes: %v", err)
+		worktree.Discard(dir, formatted)
+		return
+	}
+	log("Pushed formatting changes to %d file(s).", len(formatted))
+}
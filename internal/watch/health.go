@@ -0,0 +1,144 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"auto-pr/internal/metrics"
+	"auto-pr/internal/state"
+)
+
+// Health records the repo scan loop's progress for the health endpoint
+// (--health-addr). A nil *Health is valid and records nothing.
+type Health struct {
+	stateDir *state.Dir
+	interval time.Duration
+	started  time.Time
+
+	mu       sync.Mutex
+	lastScan time.Time
+	scans    int
+	budget   int
+	workers  []int
+}
+
+// NewHealth creates a Health for a loop polling every interval seconds.
+func NewHealth(stateDir *state.Dir, interval int) *Health {
+	return &Health{stateDir: stateDir, interval: time.Duration(interval) * time.Second, started: time.Now()}
+}
+
+// scanned records a completed scan and the workers active after it.
+func (h *Health) scanned(budget int, workers []int) {
+	if h == nil {
+		return
+	}
+	sort.Ints(workers)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastScan = time.Now()
+	h.scans++
+	h.budget = budget
+	h.workers = workers
+}
+
+// alive reports whether the loop has scanned recently enough: within three
+// poll intervals plus two minutes for a slow scan (or a webhook-driven one)
+// of the last scan, or of startup.
+func (h *Health) alive() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	last := h.lastScan
+	if last.IsZero() {
+		last = h.started
+	}
+	return time.Since(last) < 3*h.interval+2*time.Minute
+}
+
+// healthStatus is the /status response.
+type healthStatus struct {
+	Alive         bool       `json:"alive"`
+	Ready         bool       `json:"ready"`
+	StartedAt     time.Time  `json:"started_at"`
+	LastScan      *time.Time `json:"last_scan,omitempty"`
+	Scans         int        `json:"scans"`
+	Budget        int        `json:"budget"`
+	ActiveWorkers int        `json:"active_workers"`
+	Issues        []int      `json:"issues"`
+}
+
+func (h *Health) status() healthStatus {
+	alive := h.alive()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := healthStatus{
+		Alive:         alive,
+		Ready:         h.scans > 0 && h.stateDir.IsInitialized(),
+		StartedAt:     h.started,
+		Scans:         h.scans,
+		Budget:        h.budget,
+		ActiveWorkers: len(h.workers),
+		Issues:        append([]int{}, h.workers...),
+	}
+	if !h.lastScan.IsZero() {
+		last := h.lastScan
+		s.LastScan = &last
+	}
+	return s
+}
+
+// ServeHealth serves /healthz (200 while the scan loop is alive), /readyz
+// (200 once this process completed its first scan) and /status (JSON) on
+// addr until ctx is done.
+func ServeHealth(ctx context.Context, addr string, h *Health) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !h.alive() {
+			http.Error(w, "scan loop stalled", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !h.status().Ready {
+			http.Error(w, "first scan not completed", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.status())
+	})
+
+	return serveHTTP(ctx, addr, "Health endpoint", mux)
+}
+
+// ServeMetrics serves the Prometheus metrics on addr (/metrics) until ctx is
+// done.
+func ServeMetrics(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	return serveHTTP(ctx, addr, "Metrics endpoint", mux)
+}
+
+// serveHTTP serves handler on addr until ctx is done.
+func serveHTTP(ctx context.Context, addr, what string, handler http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	infof("%s listening on %s", what, addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		errorf("%s error: %v", what, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package watch
+
+import (
+	"fmt"
+	"strings"
+
+	"auto-pr/internal/ignore"
+	"auto-pr/internal/worktree"
+)
+
+// ignoreConstraint is the edit-scope rule for .autoprignore, or "" when the
+// repo has none.
+func ignoreConstraint(m *ignore.Matcher) string {
+	patterns := m.Patterns()
+	if len(patterns) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n- Do NOT modify, create or delete files matching these %s patterns (gitignore syntax); changes to them are reverted automatically: %s",
+		ignore.FileName, strings.Join(patterns, ", "))
+}
+
+// ignoredPrompt tells Claude which of its changes were reverted.
+func ignoredPrompt(paths []string) string {
+	return fmt.Sprintf(`Your last changes touched files protected by %s: %s
+auto-pr reverted those changes and pushed the revert. Do not modify these files.
+If the task still needs other changes, make them and commit and push as before. If it cannot be done without the protected files, say so in your reply instead of editing them.`,
+		ignore.FileName, strings.Join(paths, ", "))
+}
+
+// enforceIgnore reverts the changes Claude made to .autoprignore'd files in
+// dir since commit before, pushing the revert to branch, and asks Claude once
+// (through rerun) to redo its work without them. Protected files changed
+// again by that run are reverted without another prompt. Once Claude has
+// pulled commits pushed by others since origin/<branch> was at remote, the
+// files are left alone: their changes may not be Claude's.
+func enforceIgnore(m *ignore.Matcher, dir, branch, before, remote, trailer string, rerun func(prompt string), log func(string, ...interface{})) {
+	if m == nil || before == "" {
+		return
+	}
+	for attempt := 0; ; attempt++ {
+		if remote != "" && worktree.FetchedSince(dir, branch, remote) {
+			log("Commits pushed by others were pulled in, not checking %s", ignore.FileName)
+			return
+		}
+		changed, err := worktree.ChangedSince(dir, before)
+		if err != nil {
+			log("Warning: could not check %s: %v", ignore.FileName, err)
+			return
+		}
+		touched := m.Filter(changed)
+		if len(touched) == 0 {
+			return
+		}
+		log("Warning: Claude modified file(s) protected by %s, reverting: %s", ignore.FileName, strings.Join(touched, ", "))
+		msg := "Revert changes to files protected by " + ignore.FileName
+		if err := worktree.Restore(dir, branch, before, msg, trailer, touched); err != nil {
+			log("Warning: could not revert protected files: %v", err)
+			return
+		}
+		if attempt > 0 || rerun == nil {
+			return
+		}
+		rerun(ignoredPrompt(touched))
+	}
+}
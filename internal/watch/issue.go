@@ -0,0 +1,68 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+
+	"auto-pr/internal/container"
+	"auto-pr/internal/github"
+	"auto-pr/internal/logging"
+	"auto-pr/internal/state"
+)
+
+// Issue runs a single worker for issueNum, regardless of its labels: it
+// implements the issue, opens a PR and watches its reviews like a repo-mode
+// worker, and returns when the worker does. An issue recorded as failed,
+// needing a human or pre-existing is worked on again; one that is done is not.
+func Issue(ctx context.Context, repo, projectRoot string, issueNum, interval int, once bool, cfg WorkerConfig, stateDir *state.Dir, dockerMgr *container.Manager, notifier *Notifier) error {
+	issue, err := github.GetIssue(ctx, repo, issueNum)
+	if err != nil {
+		return fmt.Errorf("fetch issue #%d: %w", issueNum, err)
+	}
+	if issue.PullRequest != nil {
+		return fmt.Errorf("#%d is a pull request; use 'auto-pr watch %d' to watch it", issueNum, issueNum)
+	}
+	if issue.State != "" && issue.State != "open" {
+		return fmt.Errorf("issue #%d is %s", issueNum, issue.State)
+	}
+	prev := stateDir.ReadIssue(issueNum)
+	if prev != nil && prev.Status == state.IssueDone {
+		return fmt.Errorf("issue #%d is already done (PR #%d)", issueNum, prev.PRNumber)
+	}
+
+	infof("Issue mode — issue #%d in %s: %s", issueNum, repo, issue.Title)
+	if dockerMgr != nil {
+		infof("Docker isolation: enabled (image: %s)", dockerMgr.ImageName)
+	}
+	logging.Infof("")
+
+	if dockerMgr != nil {
+		if err := dockerMgr.EnsureImage(ctx); err != nil {
+			if !canFallBackLocal(cfg) {
+				return fmt.Errorf("docker image build failed: %w", err)
+			}
+			warnf("WARNING: docker image unavailable (%v)", err)
+			warnf("WARNING: DOCKER_FALLBACK_LOCAL is set — running the worker on the host WITHOUT container isolation")
+			dockerMgr = nil
+		}
+	}
+
+	// Recorded like a repo-mode pick-up, so a repo watcher leaves it alone
+	// and resumes it if this run is interrupted.
+	branch := fmt.Sprintf("auto/issue-%d", issueNum)
+	stateDir.UpdateIssue(issueNum, func(s *state.IssueState) {
+		s.Status = state.IssueInProgress
+		s.Branch = branch
+		s.Labels = issue.LabelNames()
+		s.FailedReason = ""
+	})
+
+	err = RunWorker(ctx, repo, projectRoot, issueNum, interval, once, cfg, stateDir, dockerMgr, notifier)
+	if err != nil {
+		return err
+	}
+	if s := stateDir.ReadIssue(issueNum); s != nil {
+		infof("Worker for issue #%d finished (%s)", issueNum, s.Status)
+	}
+	return nil
+}
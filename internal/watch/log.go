@@ -0,0 +1,33 @@
+package watch
+
+import (
+	"fmt"
+	"io"
+
+	"auto-pr/internal/logging"
+	"auto-pr/internal/redact"
+)
+
+// Console output of the watcher, prefixed with "[pr-watch]".
+func debugf(format string, args ...interface{}) { logging.Debugf("[pr-watch] "+format, args...) }
+func infof(format string, args ...interface{})  { logging.Infof("[pr-watch] "+format, args...) }
+func warnf(format string, args ...interface{})  { logging.Warnf("[pr-watch] "+format, args...) }
+func errorf(format string, args ...interface{}) { logging.Errorf("[pr-watch] "+format, args...) }
+
+// logAuto prints a "[pr-watch]" message at the level its wording implies
+// (see logging.Of). It is the log function handed to shared helpers.
+func logAuto(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	logging.Logf(logging.Of(msg), "[pr-watch] %s", msg)
+}
+
+// workerLog returns the log function of the worker for issueNum: every
+// message is written to w (the worker's log file) and printed at the level
+// its wording implies.
+func workerLog(issueNum int, w io.Writer) func(string, ...interface{}) {
+	return func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		fmt.Fprintf(w, "[worker #%d] %s\n", issueNum, redact.String(msg))
+		logging.Logf(logging.Of(msg), "[worker #%d] %s", issueNum, msg)
+	}
+}
@@ -0,0 +1,222 @@
+package watch
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"auto-pr/internal/github"
+)
+
+// formatComments renders new review data for a prompt: inline comments
+// grouped by file and sorted by line, so Claude works through one file at a
+// time, followed by the top-level reviews.
+func formatComments(data *github.NewComments) string {
+	return renderComments(data, renderOpts{})
+}
+
+// Diff hunk handling in renderComments.
+const (
+	hunksAll    = iota // show each comment's hunk
+	hunksUnique        // refer back to a hunk already shown
+	hunksNone          // leave hunks out
+)
+
+// renderOpts trims review data to fit MAX_PROMPT_BODY_BYTES.
+type renderOpts struct {
+	hunks   int
+	bodyMax int // truncate longer comment and review bodies (0 = no limit)
+}
+
+// minBodyBytes is the least fitComments cuts a body down to.
+const minBodyBytes = 500
+
+// fitComments renders data like formatComments, within limit bytes where
+// it can (0 = no limit). Over the limit it first drops repeated diff hunks,
+// then all diff hunks, and only then truncates the comment bodies. What was
+// dropped is logged.
+func fitComments(data *github.NewComments, limit int, log func(string, ...interface{})) string {
+	full := formatComments(data)
+	if limit <= 0 || len(full) <= limit {
+		return full
+	}
+	steps := []struct {
+		opts renderOpts
+		what string
+	}{
+		{renderOpts{hunks: hunksUnique}, "dropped repeated diff hunks"},
+		{renderOpts{hunks: hunksNone}, "dropped the diff hunks"},
+	}
+	for _, step := range steps {
+		if s := renderComments(data, step.opts); len(s) <= limit {
+			log("Review data is %d bytes, over MAX_PROMPT_BODY_BYTES=%d: %s (%d bytes)", len(full), limit, step.what, len(s))
+			return s
+		}
+	}
+	bodyMax := max(limit/(len(data.InlineComments)+len(data.TopLevelReviews)), minBodyBytes)
+	s := renderComments(data, renderOpts{hunks: hunksNone, bodyMax: bodyMax})
+	log("Review data is %d bytes, over MAX_PROMPT_BODY_BYTES=%d: dropped the diff hunks and truncated bodies to %d bytes (%d bytes)", len(full), limit, bodyMax, len(s))
+	return s
+}
+
+// truncateBody cuts s to at most limit bytes (0 = no limit) on a character
+// boundary, marking how much was left out.
+func truncateBody(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("\n[truncated, %d bytes omitted]", len(s)-cut)
+}
+
+func renderComments(data *github.NewComments, opts renderOpts) string {
+	var b strings.Builder
+
+	byPath := map[string][]github.ReviewComment{}
+	var paths []string
+	for _, c := range data.InlineComments {
+		if _, ok := byPath[c.Path]; !ok {
+			paths = append(paths, c.Path)
+		}
+		byPath[c.Path] = append(byPath[c.Path], c)
+	}
+	sort.Strings(paths)
+
+	if len(paths) > 0 {
+		b.WriteString("== Inline comments, by file ==\n")
+	}
+	shown := map[string]int{} // diff hunk -> first comment showing it
+	for _, path := range paths {
+		comments := byPath[path]
+		sort.SliceStable(comments, func(i, j int) bool {
+			return sortLine(&comments[i]) < sortLine(&comments[j])
+		})
+		lines := make([]string, len(comments))
+		for i := range comments {
+			lines[i] = comments[i].LineDisplay()
+		}
+		fmt.Fprintf(&b, "\n--- In file %s, at lines %s ---\n", path, strings.Join(lines, ", "))
+		for _, c := range comments {
+			fmt.Fprintf(&b, "\n[comment_id %d] %s, @%s:\n%s\n", c.ID, lineLabel(&c), c.User.Login, indent(truncateBody(c.Body, opts.bodyMax)))
+			if c.DiffHunk == "" || opts.hunks == hunksNone {
+				continue
+			}
+			if first, ok := shown[c.DiffHunk]; ok && opts.hunks == hunksUnique {
+				fmt.Fprintf(&b, "  Code the reviewer was looking at: as for comment_id %d\n", first)
+				continue
+			}
+			shown[c.DiffHunk] = c.ID
+			fmt.Fprintf(&b, "  Code the reviewer was looking at:\n%s\n", indent(c.DiffHunk))
+		}
+	}
+
+	if len(data.TopLevelReviews) > 0 {
+		if len(paths) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("== Top-level reviews ==\n")
+		for _, r := range data.TopLevelReviews {
+			fmt.Fprintf(&b, "\n[review %d] @%s (%s):\n%s\n", r.ID, r.User.Login, r.State, indent(truncateBody(r.Body, opts.bodyMax)))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// lineLabel describes where a comment sits: "line 12", or "lines 12-18" for
+// a range, noting comments on removed code.
+func lineLabel(c *github.ReviewComment) string {
+	label := "line " + c.LineDisplay()
+	if start, end, ok := c.Span(); ok && start != end {
+		label = fmt.Sprintf("lines %d-%d (the whole block)", start, end)
+	}
+	if c.Side == "LEFT" {
+		label += " of the removed code"
+	}
+	return label
+}
+
+// sortLine orders comments within a file; comments without a line go last.
+func sortLine(c *github.ReviewComment) int {
+	if start, _, ok := c.Span(); ok {
+		return start
+	}
+	return math.MaxInt
+}
+
+// indent prefixes every line of s with two spaces.
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n  ")
+}
+
+// batchComments splits data into batches of at most max inline comments,
+// oldest first. Comments on the same file stay together where possible:
+// files are taken in order of their oldest comment and packed greedily, and
+// only a file with more than max comments is split. Top-level reviews go
+// with the first batch. max <= 0 means a single batch.
+func batchComments(data *github.NewComments, max int) []*github.NewComments {
+	if max <= 0 || len(data.InlineComments) <= max {
+		return []*github.NewComments{data}
+	}
+	comments := append([]github.ReviewComment(nil), data.InlineComments...)
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+
+	byPath := map[string][]github.ReviewComment{}
+	var paths []string
+	for _, c := range comments {
+		if _, ok := byPath[c.Path]; !ok {
+			paths = append(paths, c.Path)
+		}
+		byPath[c.Path] = append(byPath[c.Path], c)
+	}
+
+	batches := []*github.NewComments{{TopLevelReviews: data.TopLevelReviews}}
+	for _, path := range paths {
+		file := byPath[path]
+		cur := batches[len(batches)-1]
+		if len(cur.InlineComments) > 0 && len(cur.InlineComments)+len(file) > max {
+			cur = &github.NewComments{}
+			batches = append(batches, cur)
+		}
+		for len(file) > 0 {
+			room := max - len(cur.InlineComments)
+			if room == 0 {
+				cur = &github.NewComments{}
+				batches = append(batches, cur)
+				room = max
+			}
+			n := min(room, len(file))
+			cur.InlineComments = append(cur.InlineComments, file[:n]...)
+			file = file[n:]
+		}
+	}
+	return batches
+}
+
+// batchPaths lists the files a batch's inline comments touch.
+func batchPaths(batch *github.NewComments) string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, c := range batch.InlineComments {
+		if !seen[c.Path] {
+			seen[c.Path] = true
+			paths = append(paths, c.Path)
+		}
+	}
+	return strings.Join(paths, ", ")
+}
+
+// batchNote tells Claude that a round was split and it only sees part of it.
+func batchNote(i, n int) string {
+	if n <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(`
+
+【Batch %d of %d】
+This round's comments were split into %d batches, handled one run at a time. Handle ONLY the comments above, commit and push them, and reply to each; the rest will follow in the next run.`, i+1, n, n)
+}
@@ -0,0 +1,133 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"auto-pr/internal/claude"
+	"auto-pr/internal/container"
+	"auto-pr/internal/github"
+	"auto-pr/internal/state"
+	"auto-pr/internal/worktree"
+)
+
+// rebaser keeps a worker's PR branch rebased onto its base (AUTO_REBASE).
+// Conflicts are handed to Claude; after maxAttempts consecutive failures it
+// asks for a manual rebase and waits until the branch is up to date again.
+type rebaser struct {
+	repo        string
+	prNum       int
+	issueNum    int
+	wtPath      string
+	branch      string
+	maxAttempts int
+	opts        claude.Options
+	stateDir    *state.Dir
+	logFile     io.Writer
+	dockerMgr   *container.Manager
+	containerID string
+	log         func(string, ...interface{})
+
+	lastBase  string // last base commit acted on
+	attempts  int    // consecutive conflict resolutions by Claude
+	commented bool   // the manual-rebase comment was posted
+}
+
+// check rebases the branch if origin/<base> has moved past it, reporting
+// whether the branch was rewritten.
+func (r *rebaser) check(ctx context.Context, base string) bool {
+	if err := worktree.Fetch(r.wtPath, base); err != nil {
+		r.log("Warning: auto-rebase: %v", err)
+		return false
+	}
+	baseSHA := worktree.RevParse(r.wtPath, "origin/"+base)
+	if baseSHA == "" {
+		return false
+	}
+	if worktree.IsAncestor(r.wtPath, baseSHA, "HEAD") {
+		r.attempts, r.commented = 0, false // up to date: a later conflict gets a fresh budget
+		return false
+	}
+	if baseSHA == r.lastBase || worktree.Unpushed(r.wtPath, r.branch) {
+		return false // already handled, or work in progress
+	}
+	r.lastBase = baseSHA
+
+	r.log("PR #%d: '%s' moved to %.7s, rebasing...", r.prNum, base, baseSHA)
+	conflicts, err := worktree.StartRebase(r.wtPath, base)
+	if err != nil && len(conflicts) == 0 {
+		r.log("Warning: auto-rebase onto '%s' failed: %v", base, err)
+		return false
+	}
+	if len(conflicts) > 0 {
+		if r.attempts >= r.maxAttempts {
+			worktree.AbortRebase(r.wtPath)
+			r.giveUp(ctx, base)
+			return false
+		}
+		r.attempts++
+		r.log("PR #%d: rebase conflicts in %s, asking Claude to resolve them (attempt %d/%d)",
+			r.prNum, strings.Join(conflicts, ", "), r.attempts, r.maxAttempts)
+		r.run(ctx, buildRebasePrompt(r.prNum, base, r.branch, conflicts, worktree.Status(r.wtPath)))
+		if worktree.RebaseInProgress(r.wtPath) || len(worktree.Conflicts(r.wtPath)) > 0 {
+			worktree.AbortRebase(r.wtPath)
+			r.log("PR #%d: conflicts were not resolved, rebase aborted.", r.prNum)
+			if r.attempts >= r.maxAttempts {
+				r.giveUp(ctx, base)
+			}
+			return false
+		}
+	}
+	if err := worktree.ForcePush(r.wtPath, r.branch); err != nil {
+		r.log("Warning: could not push the rebased branch: %v", err)
+		worktree.ResetToRemote(r.wtPath, r.branch)
+		return false
+	}
+	// Record the new head so checkHead does not mistake our push for someone
+	// else's force-push.
+	head := worktree.Head(r.wtPath)
+	r.stateDir.UpdatePR(r.prNum, func(s *state.PRState) { s.HeadSHA = head })
+	r.attempts, r.commented = 0, false
+	r.log("PR #%d: rebased onto '%s' (%.7s) and force-pushed.", r.prNum, base, baseSHA)
+	return true
+}
+
+// giveUp asks for a manual rebase, once until the branch is up to date again.
+func (r *rebaser) giveUp(ctx context.Context, base string) {
+	if r.commented {
+		return
+	}
+	r.commented = true
+	r.log("PR #%d: rebase still conflicts after %d attempt(s), leaving it for a human.", r.prNum, r.attempts)
+	body := fmt.Sprintf("⚠️ auto-pr could not rebase this branch onto `%s`: the conflicts could not be resolved automatically after %d attempt(s). This PR needs a manual rebase.", base, r.attempts)
+	if err := github.CommentOnIssue(ctx, r.repo, r.prNum, body); err != nil {
+		r.log("Warning: could not comment on PR #%d: %v", r.prNum, err)
+	}
+}
+
+func (r *rebaser) run(ctx context.Context, prompt string) {
+	res, err := runClaudeContinue(ctx, r.dockerMgr, r.containerID, r.wtPath, prompt, r.opts, r.logFile)
+	recordUsage(r.stateDir, r.issueNum, res, r.log)
+	if err != nil {
+		r.log("Warning: claude exited with error during rebase: %v", err)
+	}
+}
+
+func buildRebasePrompt(prNum int, base, branch string, conflicts []string, status string) string {
+	return fmt.Sprintf(`The base branch '%s' of PR #%d moved on, and rebasing the PR branch %s onto origin/%s stopped with merge conflicts.
+
+Conflicted files:
+- %s
+
+git status:
+%s
+Your task:
+1. Resolve the conflicts in each file, keeping the intent of both the PR's changes and the changes on '%s'
+2. git add the resolved files, then run: GIT_EDITOR=true git rebase --continue
+3. Repeat until the rebase is complete (git status shows no rebase in progress)
+
+Constraints: Do NOT push — auto-pr force-pushes the result once the rebase is complete. Do not make changes beyond what resolving the conflicts requires. If a conflict cannot be resolved sensibly, run git rebase --abort and explain why in your reply.`,
+		base, prNum, branch, base, strings.Join(conflicts, "\n- "), status, base)
+}
@@ -3,52 +3,77 @@ package watch
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
-	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"auto-pr/internal/container"
+	"auto-pr/internal/ghcli"
 	"auto-pr/internal/github"
+	"auto-pr/internal/logging"
+	"auto-pr/internal/metrics"
 	"auto-pr/internal/state"
+	"auto-pr/internal/sysload"
 	"auto-pr/internal/worktree"
 )
 
 // Repo runs the repo-level watcher that scans for new issues and spawns worker goroutines.
-func Repo(ctx context.Context, repo, projectRoot string, interval, maxConcurrent int, once bool, cfg WorkerConfig, stateDir *state.Dir, dockerMgr *container.Manager) error {
-	fmt.Printf("[pr-watch] Repo mode — watching %s\n", repo)
-	fmt.Printf("[pr-watch] Config: interval=%ds, max_concurrent=%d, issue_labels=%s\n", interval, maxConcurrent, cfg.IssueLabels)
-	fmt.Printf("[pr-watch] Worktree dir: %s\n", cfg.WorktreeDir)
+func Repo(ctx context.Context, repo, projectRoot string, interval, maxConcurrent int, once bool, cfg WorkerConfig, stateDir *state.Dir, dockerMgr *container.Manager, notifier *Notifier) error {
+	infof("Repo mode — watching %s", repo)
+	infof("Config: interval=%ds, max_concurrent=%d, issue_labels=%s", interval, maxConcurrent, cfg.IssueLabels)
+	infof("Worktree dir: %s", cfg.WorktreeDir)
 	if dockerMgr != nil {
-		fmt.Printf("[pr-watch] Docker isolation: enabled (image: %s)\n", dockerMgr.ImageName)
+		infof("Docker isolation: enabled (image: %s)", dockerMgr.ImageName)
 	}
-	fmt.Println("[pr-watch] Workers handle: Issue implementation → PR creation → Review watching")
-	fmt.Println()
+	if cfg.AdaptiveConcurrency {
+		if _, ok := sysload.Read(); ok {
+			infof("Adaptive concurrency: %d-%d workers depending on system load", min(cfg.MinConcurrent, maxConcurrent), maxConcurrent)
+		} else {
+			warnf("Warning: ADAPTIVE_CONCURRENCY is set but system load is not available here, using max_concurrent=%d", maxConcurrent)
+		}
+	}
+	if cfg.WorkHours != nil {
+		infof("Work hours: %s", cfg.WorkHours)
+	}
+	infof("Workers handle: Issue implementation → PR creation → Review watching")
+	logging.Infof("")
 
 	// Ensure Docker image exists if Docker mode is enabled
 	if dockerMgr != nil {
 		if err := dockerMgr.EnsureImage(ctx); err != nil {
-			return fmt.Errorf("docker image build failed: %w", err)
+			if !canFallBackLocal(cfg) {
+				return fmt.Errorf("docker image build failed: %w", err)
+			}
+			warnf("WARNING: docker image unavailable (%v)", err)
+			warnf("WARNING: DOCKER_FALLBACK_LOCAL is set — running workers on the host WITHOUT container isolation")
+			dockerMgr = nil
 		}
 	}
+	if dockerMgr != nil {
+		cleanupOrphanContainers(ctx, dockerMgr)
+	}
 
 	sem := make(chan struct{}, maxConcurrent)
 	var wg sync.WaitGroup
 	activeWorkers := make(map[int]context.CancelFunc) // issueNum -> cancel
 	var mu sync.Mutex
+	budget := maxConcurrent
+	resume := resumableIssues(projectRoot, cfg, stateDir)
+	workHours := true // within WORK_HOURS at the last scan
 
 	defer func() {
-		fmt.Println()
-		fmt.Println("[pr-watch] Shutting down, terminating workers...")
+		logging.Infof("")
+		infof("Shutting down, terminating workers...")
 		mu.Lock()
 		for num, cancel := range activeWorkers {
-			fmt.Printf("[pr-watch] Cancelling worker for issue #%d\n", num)
+			infof("Cancelling worker for issue #%d", num)
 			cancel()
 		}
 		mu.Unlock()
-		wg.Wait()
-		fmt.Println("[pr-watch] Goodbye.")
+		drainWorkers(&wg, cfg.ShutdownTimeout, activeWorkers, &mu, dockerMgr)
+		infof("Goodbye.")
 	}()
 
 	for {
@@ -58,14 +83,14 @@ func Repo(ctx context.Context, repo, projectRoot string, interval, maxConcurrent
 		default:
 		}
 
-		fmt.Printf("[pr-watch] %s Scanning...\n", time.Now().Format("15:04:05"))
+		debugf("%s Scanning...", time.Now().Format("15:04:05"))
 
 		// 1. Monitor workers — check for completed/failed
 		mu.Lock()
 		for num, cancel := range activeWorkers {
 			issueState := stateDir.ReadIssue(num)
-			if issueState != nil && (issueState.Status == state.IssueDone || issueState.Status == state.IssueFailed) {
-				fmt.Printf("[pr-watch] Worker for issue #%d finished (%s)\n", num, issueState.Status)
+			if issueState != nil && (issueState.Status == state.IssueDone || issueState.Status == state.IssueFailed || issueState.Status == state.IssueNeedsHuman) {
+				infof("Worker for issue #%d finished (%s)", num, issueState.Status)
 				cancel()
 				delete(activeWorkers, num)
 			}
@@ -73,157 +98,369 @@ func Repo(ctx context.Context, repo, projectRoot string, interval, maxConcurrent
 		activeCount := len(activeWorkers)
 		mu.Unlock()
 
-		// 2. Clean up stale worktrees
+		// 2. Clean up stale worktrees, archive old issue state
 		cleanupStaleWorktrees(ctx, repo, projectRoot, cfg.WorktreeDir, stateDir)
+		archiveIssues(stateDir, cfg.ArchiveAfter)
 
-		// 3. Scan for new issues
-		scanAndSpawnWorkers(ctx, repo, projectRoot, interval, once, cfg, stateDir, sem, &wg, activeWorkers, &mu, dockerMgr)
+		// 3. Scan for new issues, within the current concurrency budget
+		newBudget, load := concurrencyBudget(cfg, maxConcurrent)
+		if newBudget != budget {
+			infof("Concurrency budget %d -> %d (load %.2f, %d MiB available)", budget, newBudget, load.Load1, load.MemAvail>>20)
+			budget = newBudget
+		}
+		resume = resumeWorkers(ctx, repo, projectRoot, resume, interval, once, cfg, stateDir, sem, budget, &wg, activeWorkers, &mu, dockerMgr, notifier)
+		if open := cfg.WorkHours.Open(time.Now()); open != workHours {
+			workHours = open
+			if open {
+				infof("Within WORK_HOURS again, picking up new issues")
+			} else {
+				infof("Outside WORK_HOURS (%s), not starting new issues until then; running workers carry on", cfg.WorkHours)
+			}
+		}
+		if workHours {
+			scanAndSpawnWorkers(ctx, repo, projectRoot, interval, once, cfg, stateDir, sem, budget, &wg, activeWorkers, &mu, dockerMgr, notifier)
+		}
 
 		mu.Lock()
 		activeCount = len(activeWorkers)
+		active := make([]int, 0, activeCount)
+		for num := range activeWorkers {
+			active = append(active, num)
+		}
 		mu.Unlock()
-		fmt.Printf("[pr-watch] Active workers: %d/%d\n", activeCount, maxConcurrent)
+		cfg.Health.scanned(budget, active)
+		debugf("Active workers: %d/%d", activeCount, budget)
 
 		if once {
 			if activeCount > 0 {
-				fmt.Printf("[pr-watch] --once mode, waiting for %d active worker(s) to finish...\n", activeCount)
+				infof("--once mode, waiting for %d active worker(s) to finish...", activeCount)
 				wg.Wait()
 			}
-			fmt.Println("[pr-watch] --once mode, exiting.")
+			infof("--once mode, exiting.")
 			return nil
 		}
 
-		fmt.Printf("[pr-watch] Sleeping %ds...\n", interval)
+		debugf("Sleeping %ds...", interval)
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-time.After(time.Duration(interval) * time.Second):
+		case <-notifier.ScanC():
 		}
 	}
 }
 
-func scanAndSpawnWorkers(ctx context.Context, repo, projectRoot string, interval int, once bool, cfg WorkerConfig, stateDir *state.Dir, sem chan struct{}, wg *sync.WaitGroup, activeWorkers map[int]context.CancelFunc, mu *sync.Mutex, dockerMgr *container.Manager) {
+func scanAndSpawnWorkers(ctx context.Context, repo, projectRoot string, interval int, once bool, cfg WorkerConfig, stateDir *state.Dir, sem chan struct{}, budget int, wg *sync.WaitGroup, activeWorkers map[int]context.CancelFunc, mu *sync.Mutex, dockerMgr *container.Manager, notifier *Notifier) {
 	if cfg.IssueLabels == "" {
 		return
 	}
 
-	issues, err := github.FetchIssuesWithLabels(ctx, repo, cfg.IssueLabels)
+	issues, err := github.FetchIssuesWithLabels(ctx, repo, cfg.IssueLabels, cfg.IssueExcludeLabels)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[pr-watch] Warning: Failed to fetch issues: %v\n", err)
+		warnf("Warning: Failed to fetch issues: %v", err)
 		return
 	}
 
+	metrics.IssuesScanned.Add(float64(len(issues)))
+
+	// Highest priority first; creation order within a priority. Deferred
+	// issues are not recorded, so the next scan re-sorts them the same way.
+	sortByPriority(issues, cfg.PriorityLabels)
+
+	started := 0
 	for _, issue := range issues {
-		// Check if already known (in_progress, watching, done, failed — skip)
-		if s := stateDir.ReadIssue(issue.Number); s != nil {
+		// Check if already known (in_progress, watching, done, failed — skip).
+		// Issues skipped for lacking detail get another look once edited.
+		detailed := bodyLength(issue.Body) >= cfg.MinIssueBodyChars
+		if s := stateDir.ReadIssue(issue.Number); s != nil && (s.Status != state.IssueInsufficientDetail || !detailed) {
+			continue
+		}
+		if !detailed {
+			skipUndetailed(ctx, repo, &issue, cfg, stateDir)
+			continue
+		}
+
+		var trigger *github.IssueComment
+		if cfg.TriggerComment != "" {
+			if trigger = findTrigger(ctx, repo, issue.Number, cfg.TriggerComment, cfg.TriggerUsers); trigger == nil {
+				continue // not kicked off yet
+			}
+		}
+
+		if cfg.MaxIssuesPerScan > 0 && started >= cfg.MaxIssuesPerScan {
+			infof("Started %d new issue(s) this scan (MAX_ISSUES_PER_SCAN), deferring issue #%d", started, issue.Number)
 			continue
 		}
 
-		fmt.Printf("[pr-watch] New issue #%d: %s\n", issue.Number, issue.Title)
+		infof("New issue #%d: %s", issue.Number, issue.Title)
 
 		// Try to acquire a slot
+		mu.Lock()
+		active := len(activeWorkers)
+		mu.Unlock()
+		if active >= budget {
+			infof("Concurrency budget (%d) reached, deferring issue #%d", budget, issue.Number)
+			continue
+		}
 		select {
 		case sem <- struct{}{}:
 			// Got a slot — spawn worker
 		default:
-			fmt.Printf("[pr-watch] No slots available, deferring issue #%d\n", issue.Number)
+			infof("No slots available, deferring issue #%d", issue.Number)
 			continue
 		}
 
 		issueNum := issue.Number
 		branch := fmt.Sprintf("auto/issue-%d", issueNum)
 
-		stateDir.WriteIssue(issueNum, &state.IssueState{
+		is := &state.IssueState{
 			Status: state.IssueInProgress,
 			Branch: branch,
-		})
+			Labels: issue.LabelNames(),
+		}
+		if trigger != nil {
+			infof("Issue #%d triggered by @%s", issueNum, trigger.User.Login)
+			is.TriggerCommentID = trigger.ID
+		}
+		stateDir.WriteIssue(issueNum, is)
 
-		workerCtx, cancel := context.WithCancel(ctx)
-		mu.Lock()
-		activeWorkers[issueNum] = cancel
-		mu.Unlock()
+		spawnWorker(ctx, repo, projectRoot, issueNum, interval, once, cfg, stateDir, sem, wg, activeWorkers, mu, dockerMgr, notifier)
+		started++
+	}
+}
 
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			defer func() { <-sem }()
-			defer func() {
-				mu.Lock()
-				delete(activeWorkers, issueNum)
-				mu.Unlock()
-			}()
-
-			fmt.Printf("[pr-watch] Spawned worker for issue #%d\n", issueNum)
-
-			if err := RunWorker(workerCtx, repo, projectRoot, issueNum, interval, once, cfg, stateDir, dockerMgr); err != nil {
-				fmt.Fprintf(os.Stderr, "[pr-watch] Worker for issue #%d failed: %v\n", issueNum, err)
-				stateDir.WriteIssue(issueNum, &state.IssueState{
-					Status: state.IssueFailed, Branch: branch,
-				})
-			}
-		}()
+// drainWorkers waits for the cancelled workers to return, at most timeout
+// seconds (0 = no limit). Workers still running then are abandoned: their
+// containers are removed, and their issue state is left as it is so the
+// next start resumes them.
+func drainWorkers(wg *sync.WaitGroup, timeout int, activeWorkers map[int]context.CancelFunc, mu *sync.Mutex, dockerMgr *container.Manager) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	if timeout <= 0 {
+		<-done
+		return
+	}
+	select {
+	case <-done:
+		return
+	case <-time.After(time.Duration(timeout) * time.Second):
+	}
 
-		fmt.Printf("[pr-watch] Spawned worker for issue #%d (log: %s)\n", issueNum, stateDir.LogPath(issueNum))
+	mu.Lock()
+	var stuck []int
+	for num := range activeWorkers {
+		stuck = append(stuck, num)
+	}
+	mu.Unlock()
+	sort.Ints(stuck)
+	for _, num := range stuck {
+		warnf("Worker for issue #%d did not stop within %ds, abandoning it (state kept for resume)", num, timeout)
+		if dockerMgr != nil {
+			dockerMgr.Kill(context.Background(), fmt.Sprintf("worker-issue-%d", num))
+		}
 	}
 }
 
-var issueWorktreeRE = regexp.MustCompile(`^issue-(\d+)$`)
-var prWorktreeRE = regexp.MustCompile(`^pr-(\d+)$`)
+// spawnWorker runs RunWorker for an issue in a goroutine that holds one of
+// sem's slots, which the caller has taken. A worker that fails marks the
+// issue failed; one that was cancelled (shutdown) leaves its status alone,
+// so the next start can resume it.
+func spawnWorker(ctx context.Context, repo, projectRoot string, issueNum, interval int, once bool, cfg WorkerConfig, stateDir *state.Dir, sem chan struct{}, wg *sync.WaitGroup, activeWorkers map[int]context.CancelFunc, mu *sync.Mutex, dockerMgr *container.Manager, notifier *Notifier) {
+	branch := fmt.Sprintf("auto/issue-%d", issueNum)
+	workerCtx, cancel := context.WithCancel(ctx)
+	mu.Lock()
+	activeWorkers[issueNum] = cancel
+	mu.Unlock()
+
+	metrics.WorkersSpawned.Inc()
+	metrics.ActiveWorkers.Inc()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() { <-sem }()
+		defer func() {
+			mu.Lock()
+			delete(activeWorkers, issueNum)
+			mu.Unlock()
+			metrics.ActiveWorkers.Dec()
+		}()
 
-func cleanupStaleWorktrees(ctx context.Context, repo, projectRoot, worktreeDir string, stateDir *state.Dir) {
-	wtRoot := filepath.Join(projectRoot, worktreeDir)
-	entries, err := os.ReadDir(wtRoot)
-	if err != nil {
+		infof("Spawned worker for issue #%d", issueNum)
+
+		if err := RunWorker(workerCtx, repo, projectRoot, issueNum, interval, once, cfg, stateDir, dockerMgr, notifier); err != nil {
+			// Cancelled (shutdown, or GitHub auth is gone): not a failure.
+			if workerCtx.Err() != nil || ghcli.AuthLost() {
+				infof("Worker for issue #%d stopped: %v", issueNum, err)
+				return
+			}
+			errorf("Worker for issue #%d failed: %v", issueNum, err)
+			metrics.WorkersFailed.Inc()
+			stateDir.UpdateIssue(issueNum, func(s *state.IssueState) {
+				s.Status = state.IssueFailed
+				s.Branch = branch
+				if s.FailedReason == "" {
+					s.FailedReason = state.FailedWorker
+				}
+			})
+			if cfg.UploadLogOnFailure {
+				uploadFailureLog(ctx, stateDir, issueNum)
+			}
+		}
+	}()
+
+	infof("Spawned worker for issue #%d (log: %s)", issueNum, stateDir.LogPath(issueNum))
+}
+
+// archiveIssues moves done and failed issue state that has not changed for
+// seconds to the archive (see state.Dir.Archive).
+func archiveIssues(stateDir *state.Dir, seconds int) {
+	if seconds <= 0 {
 		return
 	}
+	nums, err := stateDir.ArchiveIssues(time.Now().Add(-time.Duration(seconds) * time.Second))
+	if err != nil {
+		warnf("Warning: could not archive issue state: %v", err)
+	}
+	if len(nums) > 0 {
+		debugf("Archived state of %d done/failed issue(s)", len(nums))
+	}
+}
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
+// resumableIssues returns the issues left in_progress or watching by an
+// earlier run whose worktree is still there, in ascending order. Their
+// workers are restarted (see adoptWorktree); issues whose worktree is gone
+// are reported and left alone.
+func resumableIssues(projectRoot string, cfg WorkerConfig, stateDir *state.Dir) []int {
+	nums, err := stateDir.ListIssues()
+	if err != nil {
+		warnf("Warning: could not list issue state: %v", err)
+		return nil
+	}
+	var out []int
+	for _, num := range nums {
+		s := stateDir.ReadIssue(num)
+		if s == nil || (s.Status != state.IssueInProgress && s.Status != state.IssueWatching) {
+			continue
+		}
+		if _, ok := worktree.Adoptable(projectRoot, cfg.WorktreeDir, fmt.Sprintf("auto/issue-%d", num), fmt.Sprintf("issue-%d", num)); !ok {
+			warnf("Warning: issue #%d was %s but its worktree is gone; not resuming it", num, s.Status)
 			continue
 		}
-		name := entry.Name()
+		out = append(out, num)
+	}
+	return out
+}
 
-		if m := issueWorktreeRE.FindStringSubmatch(name); m != nil {
-			issueNum := parseInt(m[1])
-			issueState := stateDir.ReadIssue(issueNum)
-			if issueState != nil && (issueState.Status == state.IssueInProgress || issueState.Status == state.IssueWatching) {
-				continue // active worker
-			}
+// resumeWorkers restarts workers for the issues in pending while the budget
+// and sem allow, and returns the ones still waiting for a slot.
+func resumeWorkers(ctx context.Context, repo, projectRoot string, pending []int, interval int, once bool, cfg WorkerConfig, stateDir *state.Dir, sem chan struct{}, budget int, wg *sync.WaitGroup, activeWorkers map[int]context.CancelFunc, mu *sync.Mutex, dockerMgr *container.Manager, notifier *Notifier) []int {
+	for i, num := range pending {
+		mu.Lock()
+		active := len(activeWorkers)
+		mu.Unlock()
+		if active >= budget {
+			return pending[i:]
+		}
+		select {
+		case sem <- struct{}{}:
+		default:
+			return pending[i:]
+		}
+		infof("Resuming worker for issue #%d", num)
+		spawnWorker(ctx, repo, projectRoot, num, interval, once, cfg, stateDir, sem, wg, activeWorkers, mu, dockerMgr, notifier)
+	}
+	return nil
+}
 
-			issue, err := github.GetIssue(ctx, repo, issueNum)
-			if err != nil {
-				continue
-			}
-			if issue.State == "closed" {
-				fmt.Printf("[pr-watch] Issue #%d is closed, removing worktree...\n", issueNum)
-				wtPath := filepath.Join(wtRoot, name)
-				if err := worktree.Remove(projectRoot, wtPath); err != nil {
-					fmt.Fprintf(os.Stderr, "[pr-watch] Warning: %v\n", err)
-				}
-			}
-		} else if m := prWorktreeRE.FindStringSubmatch(name); m != nil {
-			prNum := parseInt(m[1])
-			prState, err := github.GetPRState(ctx, repo, prNum)
-			if err != nil {
-				continue
-			}
-			if prState == "closed" || prState == "merged" {
-				fmt.Printf("[pr-watch] PR #%d is %s, removing worktree...\n", prNum, prState)
-				wtPath := filepath.Join(wtRoot, name)
-				if err := worktree.Remove(projectRoot, wtPath); err != nil {
-					fmt.Fprintf(os.Stderr, "[pr-watch] Warning: %v\n", err)
-				}
+// sortByPriority stably sorts issues so those carrying earlier labels in the
+// comma-separated priority list come first.
+func sortByPriority(issues []github.Issue, priorityLabels string) {
+	var prio []string
+	for _, l := range strings.Split(priorityLabels, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			prio = append(prio, l)
+		}
+	}
+	if len(prio) == 0 {
+		return
+	}
+	rank := func(issue *github.Issue) int {
+		for i, l := range prio {
+			if issue.HasLabel(l) {
+				return i
 			}
 		}
+		return len(prio)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return rank(&issues[i]) < rank(&issues[j])
+	})
+}
+
+// bodyLength counts the characters of an issue body, ignoring surrounding
+// whitespace.
+func bodyLength(body string) int {
+	return utf8.RuneCountInString(strings.TrimSpace(body))
+}
+
+// skipUndetailed records an issue as insufficient_detail and, if configured,
+// asks the reporter for more.
+func skipUndetailed(ctx context.Context, repo string, issue *github.Issue, cfg WorkerConfig, stateDir *state.Dir) {
+	infof("Skipping issue #%d: body has %d characters, MIN_ISSUE_BODY_CHARS is %d",
+		issue.Number, bodyLength(issue.Body), cfg.MinIssueBodyChars)
+	stateDir.WriteIssue(issue.Number, &state.IssueState{
+		Status: state.IssueInsufficientDetail,
+		Labels: issue.LabelNames(),
+	})
+	if cfg.InsufficientDetailComment == "" {
+		return
+	}
+	if err := github.CommentOnIssue(ctx, repo, issue.Number, cfg.InsufficientDetailComment); err != nil {
+		warnf("Warning: could not comment on issue #%d: %v", issue.Number, err)
+	}
+}
+
+// findTrigger returns the first comment on the issue that starts with the
+// trigger and was posted by a trusted user (see trustedAuthor), or nil.
+func findTrigger(ctx context.Context, repo string, issueNum int, trigger, users string) *github.IssueComment {
+	comments, err := github.FetchIssueComments(ctx, repo, issueNum)
+	if err != nil {
+		warnf("Warning: issue #%d: %v", issueNum, err)
+		return nil
+	}
+	for i := range comments {
+		c := &comments[i]
+		if strings.HasPrefix(strings.TrimSpace(c.Body), trigger) && trustedAuthor(c, users) {
+			return c
+		}
 	}
+	return nil
 }
 
-func parseInt(s string) int {
-	n := 0
-	for _, ch := range s {
-		if ch >= '0' && ch <= '9' {
-			n = n*10 + int(ch-'0')
+// trustedAuthor reports whether a comment's author may direct the bot: one
+// of the comma-separated users, or, if users is empty, anyone who owns,
+// belongs to or collaborates on the repo.
+func trustedAuthor(c *github.IssueComment, users string) bool {
+	var allow []string
+	for _, u := range strings.Split(users, ",") {
+		if u = strings.TrimPrefix(strings.TrimSpace(u), "@"); u != "" {
+			allow = append(allow, u)
+		}
+	}
+	if len(allow) == 0 {
+		switch c.AuthorAssociation {
+		case "OWNER", "MEMBER", "COLLABORATOR":
+			return true
+		}
+		return false
+	}
+	for _, u := range allow {
+		if strings.EqualFold(u, c.User.Login) {
+			return true
 		}
 	}
-	return n
+	return false
 }
@@ -0,0 +1,75 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"auto-pr/internal/github"
+	"auto-pr/internal/worktree"
+)
+
+// Edit scope enforcement modes (EDIT_SCOPE).
+const (
+	ScopeOff     = "off"     // trust the prompt's edit-scope constraints
+	ScopeRevert  = "revert"  // revert changes to files no inline comment refers to
+	ScopeComment = "comment" // revert them and say so on the PR
+)
+
+// enforceScope reverts the changes Claude made in dir since commit before to
+// files that none of the batch's inline comments is on, pushing the revert to
+// branch. Batches with top-level review feedback are left alone, since such
+// reviews may ask for changes anywhere. So are runs in which Claude pulled
+// commits pushed by others since origin/<branch> was at remote: their
+// changes are not Claude's to revert.
+func enforceScope(ctx context.Context, repo string, prNum int, mode string, batch *github.NewComments, dir, branch, before, remote, trailer string, log func(string, ...interface{})) {
+	if mode == ScopeOff || before == "" || len(batch.InlineComments) == 0 {
+		return
+	}
+	if remote != "" && worktree.FetchedSince(dir, branch, remote) {
+		log("PR #%d: commits pushed by others were pulled in, not checking the edit scope", prNum)
+		return
+	}
+	for _, r := range batch.TopLevelReviews {
+		if strings.TrimSpace(r.Body) != "" {
+			return
+		}
+	}
+	allowed := map[string]bool{}
+	for _, c := range batch.InlineComments {
+		allowed[c.Path] = true
+	}
+
+	changed, err := worktree.ChangedSince(dir, before)
+	if err != nil {
+		log("Warning: could not check edit scope: %v", err)
+		return
+	}
+	var outside []string
+	for _, p := range changed {
+		if !allowed[p] {
+			outside = append(outside, p)
+		}
+	}
+	if len(outside) == 0 {
+		return
+	}
+
+	log("Warning: PR #%d: Claude changed file(s) outside the review comments' scope, reverting: %s", prNum, strings.Join(outside, ", "))
+	if err := worktree.Restore(dir, branch, before, "Revert changes outside the review scope", trailer, outside); err != nil {
+		log("Warning: could not revert out-of-scope changes: %v", err)
+		return
+	}
+	if mode != ScopeComment {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("auto-pr reverted changes to files no review comment referred to:\n\n")
+	for _, p := range outside {
+		fmt.Fprintf(&b, "- `%s`\n", p)
+	}
+	b.WriteString("\nIf these changes are wanted, leave a comment on the file asking for them.")
+	if err := github.CommentOnIssue(ctx, repo, prNum, b.String()); err != nil {
+		log("Warning: could not comment on PR #%d: %v", prNum, err)
+	}
+}
@@ -2,64 +2,93 @@ package watch
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"time"
 
 	"auto-pr/internal/claude"
 	"auto-pr/internal/container"
 	"auto-pr/internal/github"
+	"auto-pr/internal/ignore"
+	"auto-pr/internal/logging"
+	"auto-pr/internal/metrics"
 	"auto-pr/internal/state"
+	"auto-pr/internal/worktree"
 )
 
 // SinglePR watches a single PR for new review comments and processes them with Claude.
-func SinglePR(ctx context.Context, repo, projectRoot string, prNum, interval int, once bool, stateDir *state.Dir, dockerMgr *container.Manager) error {
-	// Read or init state
+func SinglePR(ctx context.Context, repo, projectRoot string, prNum, interval int, once bool, cfg WorkerConfig, stateDir *state.Dir, dockerMgr *container.Manager, notifier *Notifier) error {
+	// Read or init the cursor
+	var cursor github.Cursor
 	prState := stateDir.ReadPR(prNum)
-	var lastTS string
-	if prState != nil {
-		lastTS = prState.LastCommentTS
-	}
-
-	if lastTS == "" {
-		fmt.Println("[pr-watch] First run — recording current comment state...")
-		ts, err := github.GetLatestCommentTimestamp(ctx, repo, prNum)
+	switch {
+	case prState != nil && prState.HasCursor():
+		cursor = cursorOf(prState)
+		infof("Resuming after comment #%d / review #%d (last activity: %s)",
+			cursor.CommentID, cursor.ReviewID, displayTS(cursor.Timestamp))
+	case prState != nil && prState.LastCommentTS != "":
+		// State from before ID cursors: everything before the stored
+		// timestamp counts as processed.
+		cur, err := github.GetCursorAsOf(ctx, repo, prNum, github.ParseTime(prState.LastCommentTS))
+		if err != nil {
+			return fmt.Errorf("migrate PR state: %w", err)
+		}
+		cursor = cur
+		saveCursor(stateDir, prNum, cursor)
+		infof("Migrated timestamp %s to comment #%d / review #%d",
+			prState.LastCommentTS, cursor.CommentID, cursor.ReviewID)
+	default:
+		infof("First run — recording current comment state...")
+		cur, err := github.GetLatestCursor(ctx, repo, prNum)
 		if err != nil {
-			ts = ""
+			return fmt.Errorf("record baseline: %w", err)
 		}
-		if ts != "" {
-			stateDir.WritePR(prNum, &state.PRState{LastCommentTS: ts})
-			fmt.Printf("[pr-watch] Baseline timestamp: %s\n", ts)
-			lastTS = ts
+		cursor = cur
+		saveCursor(stateDir, prNum, cursor)
+		if !cursor.Timestamp.IsZero() {
+			infof("Baseline: comment #%d / review #%d (%s)", cursor.CommentID, cursor.ReviewID, displayTS(cursor.Timestamp))
 		} else {
-			lastTS = "1970-01-01T00:00:00Z"
-			stateDir.WritePR(prNum, &state.PRState{LastCommentTS: lastTS})
-			fmt.Println("[pr-watch] No existing comments found, watching for new ones.")
+			infof("No existing comments found, watching for new ones.")
 		}
-	} else {
-		fmt.Printf("[pr-watch] Resuming from timestamp: %s\n", lastTS)
 	}
 
-	fmt.Printf("[pr-watch] Watching PR #%d on %s (interval: %ds)\n\n", prNum, repo, interval)
+	infof("Watching PR #%d on %s (interval: %ds)", prNum, repo, interval)
+
+	// A fork's branch cannot be fetched from or pushed to origin, so Claude
+	// would have nowhere to push its fixes.
+	watchOnly := false
+	if pr, err := github.GetPR(ctx, repo, prNum); err == nil && pr.IsFork(repo) {
+		warnf("PR #%d comes from the fork %s: fork PRs are not fully supported, watching reviews only", prNum, pr.HeadRepo())
+		watchOnly = true
+	}
+	logging.Infof("")
 
 	// If Docker mode is enabled, start a container for this PR
 	var containerID string
-	if dockerMgr != nil {
+	if dockerMgr != nil && !watchOnly {
 		if err := dockerMgr.EnsureImage(ctx); err != nil {
-			return fmt.Errorf("docker image build failed: %w", err)
+			if !canFallBackLocal(cfg) {
+				return fmt.Errorf("docker image build failed: %w", err)
+			}
+			warnf("WARNING: docker image unavailable (%v)", err)
+			warnf("WARNING: DOCKER_FALLBACK_LOCAL is set — running Claude on the host WITHOUT container isolation")
+			dockerMgr = nil
 		}
+	}
+	if dockerMgr != nil {
+		cleanupOrphanContainers(ctx, dockerMgr)
 		containerName := fmt.Sprintf("worker-pr-%d", prNum)
-		fmt.Printf("[pr-watch] Starting Docker container %s...\n", containerName)
-		cid, err := dockerMgr.Start(ctx, containerName, container.GetWorkerEnv())
+		infof("Starting Docker container %s...", containerName)
+		cid, err := startContainer(ctx, dockerMgr, containerName, cfg, logAuto)
 		if err != nil {
 			return fmt.Errorf("failed to start container: %w", err)
 		}
 		containerID = cid
-		defer func() {
-			fmt.Printf("[pr-watch] Stopping container %s...\n", containerName)
-			dockerMgr.Stop(context.Background(), containerID)
-		}()
+		if containerID != "" {
+			defer func() {
+				infof("Stopping container %s...", containerName)
+				dockerMgr.Stop(context.Background(), containerID)
+			}()
+		}
 	}
 
 	for {
@@ -69,91 +98,154 @@ func SinglePR(ctx context.Context, repo, projectRoot string, prNum, interval int
 		default:
 		}
 
-		fmt.Printf("[pr-watch] %s Checking for new comments...\n", time.Now().Format("15:04:05"))
+		debugf("%s Checking for new comments...", time.Now().Format("15:04:05"))
 
-		newData, err := github.FetchNewComments(ctx, repo, prNum, lastTS)
+		newData, err := github.FetchNewComments(ctx, repo, prNum, cursor, cfg.BotLogin)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "[pr-watch] Warning: %v\n", err)
+			warnf("Warning: %v", err)
+		}
+		if newData != nil {
+			newData = dropHandled(stateDir, prNum, newData)
+		}
+		if newData != nil && cfg.DedupComments {
+			if newData = dropDuplicates(stateDir, prNum, newData); newData == nil {
+				infof("New comments duplicate already-handled ones, skipping.")
+				if cur, err := github.GetLatestCursor(ctx, repo, prNum); err == nil {
+					cursor = cur
+					saveCursor(stateDir, prNum, cursor)
+				}
+			}
+		}
+
+		var note string
+		if newData != nil {
+			if newData, note = handleConflicts(ctx, repo, prNum, newData, cfg.ConflictAction, stateDir, cfg.DedupComments, logAuto); newData == nil {
+				if cur, err := github.GetLatestCursor(ctx, repo, prNum); err == nil {
+					cursor = cur
+					saveCursor(stateDir, prNum, cursor)
+				}
+			}
 		}
 
 		if newData == nil {
-			fmt.Println("[pr-watch] No new comments.")
+			debugf("No new comments.")
 		} else {
-			fmt.Printf("[pr-watch] Found %d new inline comment(s), %d new review(s).\n",
+			infof("Found %d new inline comment(s), %d new review(s).",
 				len(newData.InlineComments), len(newData.TopLevelReviews))
 
 			// Print previews
 			for _, c := range newData.InlineComments {
-				fmt.Printf("  -> @%s on %s:%s: %s\n", c.User.Login, c.Path, c.LineDisplay(), firstLine(c.Body))
+				logging.Infof("  -> @%s on %s:%s: %s", c.User.Login, c.Path, c.LineDisplay(), firstLine(c.Body))
 			}
 			for _, r := range newData.TopLevelReviews {
-				fmt.Printf("  -> @%s [%s]: %s\n", r.User.Login, r.State, firstLine(r.Body))
+				logging.Infof("  -> @%s [%s]: %s", r.User.Login, r.State, firstLine(r.Body))
 			}
 
-			fmt.Println()
-			fmt.Println("[pr-watch] Dispatching to Claude Code...")
+			var batches []*github.NewComments
+			if watchOnly {
+				infof("Fork PR: not dispatching to Claude.")
+			} else {
+				batches = batchComments(newData, cfg.MaxCommentsPerRound)
+			}
+			if len(batches) > 1 {
+				infof("More than MAX_COMMENTS_PER_ROUND=%d inline comments, handling them in %d batches.",
+					cfg.MaxCommentsPerRound, len(batches))
+			}
+			for i, batch := range batches {
+				logging.Infof("")
+				if len(batches) > 1 {
+					infof("Batch %d/%d: %d comment(s) on %s", i+1, len(batches), len(batch.InlineComments), batchPaths(batch))
+				}
+				infof("Dispatching to Claude Code...")
 
-			dataJSON, _ := json.Marshal(newData)
-			prompt := buildSinglePRPrompt(repo, prNum, string(dataJSON))
+				prompt := buildSinglePRPrompt(repo, prNum, fitComments(batch, cfg.MaxPromptBodyBytes, infof), cfg.CommitTrailer, cfg.Ignore, cfg.Prompts) + note + batchNote(i, len(batches))
 
-			if err := runClaudeSinglePR(ctx, dockerMgr, containerID, projectRoot, prompt); err != nil {
-				fmt.Fprintf(os.Stderr, "[pr-watch] Warning: Claude Code exited with non-zero status: %v\n", err)
+				before := worktree.Head(projectRoot)
+				branch := worktree.Branch(projectRoot)
+				remote := worktree.RevParse(projectRoot, "origin/"+branch)
+				run := func(prompt string) {
+					res, err := runClaudeSinglePR(ctx, dockerMgr, containerID, projectRoot, prompt, cfg.claudeOptions(phaseReview))
+					if err != nil {
+						warnf("Warning: Claude Code exited with non-zero status: %v", err)
+					}
+					if res.InputTokens > 0 || res.OutputTokens > 0 {
+						infof("Claude usage: %d input / %d output tokens ($%.4f)", res.InputTokens, res.OutputTokens, res.CostUSD)
+					}
+				}
+				run(prompt)
+				if ctx.Err() != nil {
+					return ctx.Err() // unfinished batches are picked up again next time
+				}
+				// Each run is a fresh session, so the follow-up repeats the task.
+				enforceIgnore(cfg.Ignore, projectRoot, branch, before, remote, cfg.CommitTrailer, func(p string) {
+					run(prompt + "\n\n" + p)
+				}, logAuto)
+				enforceScope(ctx, repo, prNum, cfg.EditScope, batch, projectRoot, branch, before, remote, cfg.CommitTrailer, logAuto)
+				runFormatter(ctx, cfg.FormatCommand, dockerMgr, containerID, projectRoot, branch, before, cfg.CommitTrailer, logging.Stdout(), logAuto)
+				recordHandled(stateDir, prNum, batch, cfg.DedupComments)
 			}
 
-			fmt.Println()
-			fmt.Println("[pr-watch] Claude Code finished processing.")
+			if len(batches) > 0 {
+				metrics.ReviewRounds.Inc()
+				logging.Infof("")
+				infof("Claude Code finished processing.")
+			}
 
-			// Update timestamp
-			ts, _ := github.GetLatestCommentTimestamp(ctx, repo, prNum)
-			if ts != "" {
-				lastTS = ts
-				stateDir.WritePR(prNum, &state.PRState{LastCommentTS: lastTS})
-				fmt.Printf("[pr-watch] Updated timestamp to: %s\n", lastTS)
+			// Advance the cursor past everything seen, including our own replies
+			if cur, err := github.GetLatestCursor(ctx, repo, prNum); err == nil {
+				cursor = cur
+				saveCursor(stateDir, prNum, cursor)
+				infof("Advanced to comment #%d / review #%d (%s)", cursor.CommentID, cursor.ReviewID, displayTS(cursor.Timestamp))
 			}
 		}
 
 		if once {
-			fmt.Println("[pr-watch] --once mode, exiting.")
+			infof("--once mode, exiting.")
 			return nil
 		}
 
-		fmt.Printf("[pr-watch] Sleeping %ds...\n", interval)
+		debugf("Sleeping %ds...", interval)
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-time.After(time.Duration(interval) * time.Second):
+		case <-notifier.PRC(prNum):
 		}
 	}
 }
 
-func buildSinglePRPrompt(repo string, prNum int, data string) string {
-	return fmt.Sprintf(`New review comments on GitHub PR #%d (repo: %s). Process each one:
+func buildSinglePRPrompt(repo string, prNum int, data, trailer string, ign *ignore.Matcher, pt *Prompts) string {
+	vars := PromptVars{
+		Repo: repo, PRNum: prNum, Data: data,
+		Trailer: trailer, TrailerInstruction: trailerInstruction(trailer),
+		IgnoreConstraint: ignoreConstraint(ign),
+	}
+	vars.Default = fmt.Sprintf(`New review comments on GitHub PR #%d (repo: %s). Process each one:
 
 %s
 
 【Edit scope constraints — MUST strictly follow】
-- You may ONLY modify files explicitly mentioned in the review comments (the 'path' field of inline comments defines your editing scope). Do NOT edit any file not referenced by a review comment.
+- You may ONLY modify files explicitly mentioned in the review comments (the file sections of the inline comments define your editing scope). Do NOT edit any file not referenced by a review comment.
 - Only change code related to the reviewer's feedback — do not refactor, reformat, or "improve" surrounding code beyond what the reviewer requested.
-- Do NOT modify project infrastructure files: CLAUDE.md, .claude/, scripts/, .gitignore, CI configs.
+- Do NOT modify project infrastructure files: CLAUDE.md, .claude/, scripts/, .gitignore, CI configs.%s
 - If a review comment is ambiguous or references files not in the PR, use ./scripts/pr-reply to ask for clarification instead of guessing.
 
-For each inline comment (items in inline_comments array):
-1. Read the file mentioned in the comment (path field) at the code location (line field)
-2. Modify the code per the reviewer's feedback (only that file)
-3. After all modifications, commit and push with a single commit
+Work through the inline comments one file section at a time:
+1. Read the file at the listed lines. The code the reviewer was looking at is shown under each comment; use it to find the right spot if the file has changed since the review.
+2. Modify the code per each comment's feedback (only that file), then move on to the next file
+3. After all modifications, commit and push with a single commit%s
 4. For each inline comment, reply using: ./scripts/pr-reply <comment_id> "brief description of what you changed"
 
-For top_level_reviews, if they contain specific modification suggestions, handle them too (same edit scope constraints).
-
-Note: The 'id' field of each comment is the comment_id needed for pr-reply.`, prNum, repo, data)
+For top-level reviews, if they contain specific modification suggestions, handle them too (same edit scope constraints).`, prNum, repo, data, vars.IgnoreConstraint, vars.TrailerInstruction)
+	return pt.renderReview(vars)
 }
 
 // runClaudeSinglePR runs claude for single-PR mode, either locally or in a Docker container.
-func runClaudeSinglePR(ctx context.Context, dockerMgr *container.Manager, containerID, projectRoot, prompt string) error {
+func runClaudeSinglePR(ctx context.Context, dockerMgr *container.Manager, containerID, projectRoot, prompt string, opts claude.Options) (claude.Result, error) {
 	if dockerMgr != nil && containerID != "" {
-		return claude.RunInContainer(ctx, dockerMgr, containerID, "/workspace", prompt, nil)
+		return claude.RunInContainer(ctx, dockerMgr, containerID, "/workspace", prompt, opts, nil)
 	}
-	return claude.Run(ctx, ".", prompt, nil)
+	return claude.Run(ctx, ".", prompt, opts, nil)
 }
 
 func firstLine(s string) string {
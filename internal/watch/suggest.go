@@ -0,0 +1,139 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"auto-pr/internal/github"
+	"auto-pr/internal/worktree"
+)
+
+// applySuggestions applies reviewers' ```suggestion blocks directly, without
+// Claude: each commented line is checked against the comment's diff_hunk,
+// replaced, and everything applied is committed and pushed in one commit and
+// answered with "Applied suggestion.". It returns the comments it handled;
+// anything it could not apply, multi-line suggestions included, is left for
+// Claude.
+func applySuggestions(ctx context.Context, repo, wtPath, branch string, comments []github.ReviewComment, trailer string, log func(string, ...interface{})) []github.ReviewComment {
+	// Group by file and apply bottom-up so earlier edits don't shift the
+	// line numbers of later ones.
+	var candidates []github.ReviewComment
+	for _, c := range comments {
+		if _, ok := github.ParseSuggestion(c.Body); ok && c.Line != nil && !multiLine(&c) {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Path != candidates[j].Path {
+			return candidates[i].Path < candidates[j].Path
+		}
+		return *candidates[i].Line > *candidates[j].Line
+	})
+
+	var applied []github.ReviewComment
+	var paths []string
+	touched := map[string]bool{}
+	lines := map[string]bool{}
+	for _, c := range candidates {
+		key := fmt.Sprintf("%s:%d", c.Path, *c.Line)
+		if lines[key] {
+			continue // a second suggestion on the same line needs judgement
+		}
+		if err := applySuggestion(wtPath, &c); err != nil {
+			log("Could not apply suggestion %d on %s:%d: %v", c.ID, c.Path, *c.Line, err)
+			continue
+		}
+		lines[key] = true
+		applied = append(applied, c)
+		if !touched[c.Path] {
+			touched[c.Path] = true
+			paths = append(paths, c.Path)
+		}
+	}
+	if len(applied) == 0 {
+		return nil
+	}
+
+	msg := "Apply suggested change"
+	if len(applied) > 1 {
+		msg = fmt.Sprintf("Apply %d suggested changes", len(applied))
+	}
+	msg += " from code review"
+	if err := worktree.CommitAndPush(wtPath, branch, msg, trailer, paths); err != nil {
+		log("Could not commit applied suggestions, falling back to Claude: %v", err)
+		worktree.Discard(wtPath, paths)
+		return nil
+	}
+	log("Applied %d suggestion(s) directly.", len(applied))
+
+	for _, c := range applied {
+		if _, err := github.ReplyToComment(ctx, repo, c.ID, "Applied suggestion."); err != nil {
+			log("Warning: could not reply to comment %d: %v", c.ID, err)
+		}
+	}
+	return applied
+}
+
+// multiLine reports whether c spans several lines. Its suggestion replaces the
+// whole span, which the diff hunk alone cannot verify.
+func multiLine(c *github.ReviewComment) bool {
+	return c.StartLine != nil && *c.StartLine != *c.Line
+}
+
+// applySuggestion replaces the line a single-line suggestion targets, after
+// checking it still matches what the reviewer saw.
+func applySuggestion(wtPath string, c *github.ReviewComment) error {
+	replacement, _ := github.ParseSuggestion(c.Body)
+	expected, ok := github.HunkLastLine(c.DiffHunk)
+	if !ok {
+		return fmt.Errorf("cannot determine commented line from diff hunk")
+	}
+
+	path := filepath.Join(wtPath, filepath.FromSlash(c.Path))
+	if rel, err := filepath.Rel(wtPath, path); err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("path %q escapes the worktree", c.Path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	content := string(data)
+	crlf := strings.Contains(content, "\r\n")
+	if crlf {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	trailingNL := strings.HasSuffix(content, "\n")
+	fileLines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	idx := *c.Line - 1
+	if idx < 0 || idx >= len(fileLines) {
+		return fmt.Errorf("line %d out of range", *c.Line)
+	}
+	if fileLines[idx] != expected {
+		return fmt.Errorf("line %d changed since the review", *c.Line)
+	}
+
+	out := append([]string{}, fileLines[:idx]...)
+	out = append(out, replacement...)
+	out = append(out, fileLines[idx+1:]...)
+	result := strings.Join(out, "\n")
+	if trailingNL {
+		result += "\n"
+	}
+	if crlf {
+		result = strings.ReplaceAll(result, "\n", "\r\n")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(result), info.Mode().Perm())
+}
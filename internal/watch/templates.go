@@ -0,0 +1,119 @@
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// PromptDir holds the prompt templates a repo can override, relative to the
+// project root.
+const PromptDir = ".pr-watch/prompts"
+
+// Prompts holds a repo's prompt templates. A nil *Prompts, or a nil
+// template, stands for the built-in prompt.
+type Prompts struct {
+	implement *template.Template // implement.tmpl: Phase 1
+	review    *template.Template // review.tmpl: review rounds and single-PR mode
+}
+
+// PromptVars are the variables available to prompt templates. Fields that
+// do not apply to a prompt are empty.
+type PromptVars struct {
+	Repo     string
+	IssueNum int    // implement: the issue number
+	Title    string // implement: the issue title
+	Body     string // implement: the issue body
+	PRNum    int    // review: the PR number
+	Branch   string // the branch Claude pushes to ("" in single-PR mode)
+	Data     string // review: the new comments, grouped by file
+
+	// Trailer is COMMIT_TRAILER; TrailerInstruction is the built-in prompt's
+	// sentence asking for it ("" when trailers are off).
+	Trailer            string
+	TrailerInstruction string
+	// IgnoreConstraint is the built-in constraint naming .autoprignore's
+	// patterns ("" without one).
+	IgnoreConstraint string
+	// Specs are the linked documents fetched for the issue, fenced as
+	// untrusted data (implement only).
+	Specs string
+	// PRCreate is the command the built-in prompt opens the PR with:
+	// "gh pr create", plus --draft with PR_DRAFT (implement only).
+	PRCreate string
+	// Default is the built-in prompt, for templates that only add to it.
+	Default string
+}
+
+// LoadPrompts loads implement.tmpl and review.tmpl from PromptDir under
+// projectRoot. Returns nil when neither exists. Templates are test-rendered
+// so that syntax errors and unknown variables fail at startup.
+func LoadPrompts(projectRoot string) (*Prompts, error) {
+	p := &Prompts{}
+	for name, t := range map[string]**template.Template{"implement.tmpl": &p.implement, "review.tmpl": &p.review} {
+		path := filepath.Join(projectRoot, PromptDir, name)
+		text, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(name).Parse(string(text))
+		if err != nil {
+			return nil, err
+		}
+		if err := tmpl.Execute(io.Discard, PromptVars{}); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		*t = tmpl
+	}
+	if p.implement == nil && p.review == nil {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// Names lists the templates in use, for the startup log.
+func (p *Prompts) Names() []string {
+	if p == nil {
+		return nil
+	}
+	var names []string
+	if p.implement != nil {
+		names = append(names, "implement.tmpl")
+	}
+	if p.review != nil {
+		names = append(names, "review.tmpl")
+	}
+	return names
+}
+
+// render executes t with vars, returning vars.Default when t is nil or fails.
+func render(t *template.Template, vars PromptVars) string {
+	if t == nil {
+		return vars.Default
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, vars); err != nil {
+		return vars.Default
+	}
+	return b.String()
+}
+
+func (p *Prompts) renderImplement(vars PromptVars) string {
+	if p == nil {
+		return vars.Default
+	}
+	return render(p.implement, vars)
+}
+
+func (p *Prompts) renderReview(vars PromptVars) string {
+	if p == nil {
+		return vars.Default
+	}
+	return render(p.review, vars)
+}
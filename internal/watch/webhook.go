@@ -0,0 +1,170 @@
+package watch
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxWebhookBody matches GitHub's 25 MB cap on webhook payloads.
+const maxWebhookBody = 25 << 20
+
+// Notifier wakes the scan loop and review watchers when webhook events
+// arrive. A nil *Notifier is valid and never fires, so polling-only callers
+// can pass nil.
+type Notifier struct {
+	mu   sync.Mutex
+	scan chan struct{}
+	prs  map[int]chan struct{}
+}
+
+// NewNotifier creates a Notifier.
+func NewNotifier() *Notifier {
+	return &Notifier{
+		scan: make(chan struct{}, 1),
+		prs:  make(map[int]chan struct{}),
+	}
+}
+
+// ScanC returns a channel that receives when an issue event requests a scan.
+func (n *Notifier) ScanC() <-chan struct{} {
+	if n == nil {
+		return nil
+	}
+	return n.scan
+}
+
+// PRC returns a channel that receives when a review event arrives for prNum.
+func (n *Notifier) PRC(prNum int) <-chan struct{} {
+	if n == nil {
+		return nil
+	}
+	return n.prChan(prNum)
+}
+
+func (n *Notifier) prChan(prNum int) chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ch, ok := n.prs[prNum]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		n.prs[prNum] = ch
+	}
+	return ch
+}
+
+func (n *Notifier) notifyScan() {
+	select {
+	case n.scan <- struct{}{}:
+	default: // a wake-up is already pending
+	}
+}
+
+func (n *Notifier) notifyPR(prNum int) {
+	select {
+	case n.prChan(prNum) <- struct{}{}:
+	default:
+	}
+}
+
+// webhookPayload holds the fields auto-pr reads from issue and review events.
+type webhookPayload struct {
+	Action string `json:"action"`
+	Issue  struct {
+		Number      int       `json:"number"`
+		PullRequest *struct{} `json:"pull_request"` // set for PR conversation comments
+	} `json:"issue"`
+	PullRequest struct {
+		Number int `json:"number"`
+	} `json:"pull_request"`
+}
+
+// Serve runs the GitHub webhook receiver on addr until ctx is cancelled.
+// Requests must carry a valid X-Hub-Signature-256 for secret. Issue and
+// issue comment events trigger an immediate scan; review events wake the
+// watcher for that PR.
+func Serve(ctx context.Context, addr, secret string, n *Notifier) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "could not read body", http.StatusBadRequest)
+			return
+		}
+		if !validSignature(secret, body, r.Header.Get("X-Hub-Signature-256")) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		event := r.Header.Get("X-GitHub-Event")
+		var p webhookPayload
+		if event != "ping" {
+			if err := json.Unmarshal(body, &p); err != nil {
+				http.Error(w, "invalid payload", http.StatusBadRequest)
+				return
+			}
+		}
+
+		switch event {
+		case "issues":
+			if p.Action == "labeled" || p.Action == "opened" || p.Action == "reopened" {
+				infof("Webhook: issue #%d %s, scanning", p.Issue.Number, p.Action)
+				n.notifyScan()
+			}
+		case "issue_comment":
+			if p.Action != "created" {
+				break
+			}
+			if p.Issue.PullRequest != nil {
+				n.notifyPR(p.Issue.Number) // may be an /auto-pr command
+			} else {
+				n.notifyScan() // may be a TRIGGER_COMMENT
+			}
+		case "pull_request_review", "pull_request_review_comment":
+			infof("Webhook: %s on PR #%d", event, p.PullRequest.Number)
+			n.notifyPR(p.PullRequest.Number)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	infof("Webhook server listening on %s", addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		errorf("Webhook server error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// validSignature checks GitHub's "sha256=<hex HMAC>" signature header.
+func validSignature(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
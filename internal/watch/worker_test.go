@@ -0,0 +1,131 @@
+package watch
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"auto-pr/internal/state"
+	"auto-pr/internal/worktree"
+)
+
+// gitEnv gives test commits an identity independent of the user's config.
+func gitEnv(t *testing.T) {
+	t.Helper()
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME":     "auto-pr test",
+		"GIT_AUTHOR_EMAIL":    "test@example.com",
+		"GIT_COMMITTER_NAME":  "auto-pr test",
+		"GIT_COMMITTER_EMAIL": "test@example.com",
+		"GIT_CONFIG_GLOBAL":   "/dev/null",
+	} {
+		t.Setenv(k, v)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestCheckHead(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	gitEnv(t)
+	tmp := t.TempDir()
+	remote := filepath.Join(tmp, "remote.git")
+	other := filepath.Join(tmp, "other") // someone else's clone
+	wt := filepath.Join(tmp, "wt")
+
+	git(t, tmp, "init", "-q", "--bare", remote)
+	git(t, tmp, "init", "-q", other)
+	git(t, other, "remote", "add", "origin", remote)
+	git(t, other, "commit", "-q", "--allow-empty", "-m", "first")
+	git(t, other, "push", "-q", "origin", "HEAD:refs/heads/feature")
+	git(t, tmp, "clone", "-q", "-b", "feature", remote, wt)
+
+	stateDir := state.New(tmp)
+	if err := stateDir.Init(); err != nil {
+		t.Fatal(err)
+	}
+	log := func(format string, args ...interface{}) { t.Logf(format, args...) }
+	const prNum = 7
+
+	first := git(t, other, "rev-parse", "HEAD")
+	if checkHead(stateDir, prNum, wt, "feature", first, log) {
+		t.Fatal("first head seen reported as a force-push")
+	}
+
+	// A fast-forward is not a force-push and leaves the worktree alone.
+	git(t, other, "commit", "-q", "--allow-empty", "-m", "second")
+	git(t, other, "push", "-q", "origin", "HEAD:feature")
+	second := git(t, other, "rev-parse", "HEAD")
+	if checkHead(stateDir, prNum, wt, "feature", second, log) {
+		t.Error("fast-forward reported as a force-push")
+	}
+	if got := stateDir.ReadPR(prNum).HeadSHA; got != second {
+		t.Errorf("recorded head = %.7s, want %.7s", got, second)
+	}
+	if got := worktree.Head(wt); got != first {
+		t.Errorf("worktree moved to %.7s on a fast-forward", got)
+	}
+
+	// Rewriting the branch resets the worktree to the new head.
+	git(t, other, "commit", "-q", "--amend", "--allow-empty", "-m", "second, rewritten")
+	git(t, other, "push", "-q", "--force", "origin", "HEAD:feature")
+	rewritten := git(t, other, "rev-parse", "HEAD")
+	if !checkHead(stateDir, prNum, wt, "feature", rewritten, log) {
+		t.Fatal("rewritten branch not reported as a force-push")
+	}
+	if got := worktree.Head(wt); got != rewritten {
+		t.Errorf("worktree at %.7s, want the new head %.7s", got, rewritten)
+	}
+	if got := stateDir.ReadPR(prNum).HeadSHA; got != rewritten {
+		t.Errorf("recorded head = %.7s, want %.7s", got, rewritten)
+	}
+
+	// The same head again is nothing new.
+	if checkHead(stateDir, prNum, wt, "feature", rewritten, log) {
+		t.Error("unchanged head reported as a force-push")
+	}
+}
+
+func TestToContainerPath(t *testing.T) {
+	type test struct{ host, root, want string }
+	tests := []test{
+		{"/home/me/repo", "/home/me/repo", "/workspace"},
+		{"/home/me/repo/", "/home/me/repo", "/workspace"},
+		{"/home/me/repo/.worktrees/issue-1", "/home/me/repo", "/workspace/.worktrees/issue-1"},
+		{"/home/me/repo/my worktrees/issue 1", "/home/me/repo", "/workspace/my worktrees/issue 1"},
+		{"/home/me/repo/..hidden", "/home/me/repo", "/workspace/..hidden"},
+		// outside the bind mount
+		{"/home/me/other", "/home/me/repo", "/workspace"},
+		{"/home/me/repo-2/wt", "/home/me/repo", "/workspace"},
+		{"/home/me", "/home/me/repo", "/workspace"},
+		{"relative/wt", "/home/me/repo", "/workspace"},
+	}
+	if runtime.GOOS == "windows" {
+		tests = []test{
+			{`C:\Users\me\repo`, `C:\Users\me\repo`, "/workspace"},
+			{`C:\Users\me\repo\.worktrees\issue-1`, `C:\Users\me\repo`, "/workspace/.worktrees/issue-1"},
+			{`c:\users\me\repo\.worktrees\issue-1`, `C:\Users\me\repo`, "/workspace/.worktrees/issue-1"},
+			{`C:/Users/me/repo/.worktrees/issue-1`, `C:\Users\me\repo`, "/workspace/.worktrees/issue-1"},
+			{`C:\Users\me\repo\my worktrees\issue 1`, `C:\Users\me\repo`, "/workspace/my worktrees/issue 1"},
+			// outside the bind mount
+			{`C:\Users\me\other`, `C:\Users\me\repo`, "/workspace"},
+			{`D:\repo\.worktrees\issue-1`, `C:\Users\me\repo`, "/workspace"},
+		}
+	}
+	for _, tt := range tests {
+		if got := toContainerPath(tt.host, tt.root); got != tt.want {
+			t.Errorf("toContainerPath(%q, %q) = %q, want %q", tt.host, tt.root, got, tt.want)
+		}
+	}
+}
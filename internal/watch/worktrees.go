@@ -0,0 +1,101 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"auto-pr/internal/github"
+	"auto-pr/internal/state"
+	"auto-pr/internal/worktree"
+)
+
+var issueWorktreeRE = regexp.MustCompile(`^issue-(\d+)$`)
+var prWorktreeRE = regexp.MustCompile(`^pr-(\d+)$`)
+
+// Worktree describes a directory under WORKTREE_DIR.
+type Worktree struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Kind   string `json:"kind"` // "issue", "pr", or "" for directories auto-pr did not create
+	Number int    `json:"number,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	// Status is the issue's local state (issue worktrees only).
+	Status state.IssueStatus `json:"status,omitempty"`
+	// Remote is the issue's or PR's state on GitHub ("open", "closed",
+	// "merged"), or "" if unknown.
+	Remote string `json:"remote,omitempty"`
+	// Orphaned worktrees belong to a closed issue (with no active worker)
+	// or a closed/merged PR, and can be removed.
+	Orphaned bool `json:"orphaned"`
+}
+
+// ListWorktrees inspects every directory under worktreeDir. GitHub is only
+// asked about issues without an active worker and PRs; when it cannot be
+// reached, the worktree is kept (not orphaned).
+func ListWorktrees(ctx context.Context, repo, projectRoot, worktreeDir string, stateDir *state.Dir) []Worktree {
+	wtRoot := filepath.Join(projectRoot, worktreeDir)
+	entries, err := os.ReadDir(wtRoot)
+	if err != nil {
+		return nil
+	}
+
+	var out []Worktree
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		wt := Worktree{Name: entry.Name(), Path: filepath.Join(wtRoot, entry.Name())}
+		wt.Branch = worktree.Branch(wt.Path)
+
+		if m := issueWorktreeRE.FindStringSubmatch(wt.Name); m != nil {
+			wt.Kind, wt.Number = "issue", parseInt(m[1])
+			if s := stateDir.ReadIssue(wt.Number); s != nil {
+				wt.Status = s.Status
+				if s.Status == state.IssueInProgress || s.Status == state.IssueWatching {
+					out = append(out, wt) // active worker
+					continue
+				}
+			}
+			if issue, err := github.GetIssue(ctx, repo, wt.Number); err == nil {
+				wt.Remote = issue.State
+				wt.Orphaned = issue.State == "closed"
+			}
+		} else if m := prWorktreeRE.FindStringSubmatch(wt.Name); m != nil {
+			wt.Kind, wt.Number = "pr", parseInt(m[1])
+			if prState, err := github.GetPRState(ctx, repo, wt.Number); err == nil {
+				wt.Remote = prState
+				wt.Orphaned = prState == "closed" || prState == "merged"
+			}
+		}
+		out = append(out, wt)
+	}
+	return out
+}
+
+func cleanupStaleWorktrees(ctx context.Context, repo, projectRoot, worktreeDir string, stateDir *state.Dir) {
+	for _, wt := range ListWorktrees(ctx, repo, projectRoot, worktreeDir, stateDir) {
+		if !wt.Orphaned {
+			continue
+		}
+		if wt.Kind == "issue" {
+			infof("Issue #%d is closed, removing worktree...", wt.Number)
+		} else {
+			infof("PR #%d is %s, removing worktree...", wt.Number, wt.Remote)
+		}
+		if err := worktree.Remove(projectRoot, wt.Path); err != nil {
+			warnf("Warning: %v", err)
+		}
+	}
+}
+
+func parseInt(s string) int {
+	n := 0
+	for _, ch := range s {
+		if ch >= '0' && ch <= '9' {
+			n = n*10 + int(ch-'0')
+		}
+	}
+	return n
+}
@@ -7,11 +7,32 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"auto-pr/internal/github"
 )
 
+// shallow makes the fetches that bring in new branches fetch only their tip
+// (WORKTREE_SHALLOW).
+var shallow bool
+
+// SetShallow turns shallow fetching on or off. Fetches that update a branch
+// the repo already has stay normal fetches: they only transfer new commits,
+// and a depth limit there would cut the branch off from its own history.
+func SetShallow(on bool) {
+	shallow = on
+}
+
+// fetchNew returns the arguments fetching refs that may not exist locally.
+func fetchNew(refs ...string) []string {
+	args := []string{"fetch"}
+	if shallow {
+		args = append(args, "--depth", "1")
+	}
+	return append(append(args, "origin"), refs...)
+}
+
 // Ensure creates or validates a git worktree.
 // Returns the absolute path to the worktree.
 func Ensure(projectRoot, worktreeDir, branch, name string) (string, error) {
@@ -22,6 +43,11 @@ func Ensure(projectRoot, worktreeDir, branch, name string) (string, error) {
 		if isValidWorktree(wtPath) {
 			fmt.Printf("[pr-watch] Worktree '%s' exists, pulling latest...\n", name)
 			gitInDir(wtPath, "fetch", "origin", branch)
+			if Unpushed(wtPath, branch) {
+				// Work of an interrupted run; a reset would lose it.
+				fmt.Printf("[pr-watch] Worktree '%s' has un-pushed work, keeping it.\n", name)
+				return wtPath, nil
+			}
 			if err := gitInDir(wtPath, "reset", "--hard", "origin/"+branch); err != nil {
 				gitInDir(wtPath, "checkout", branch)
 			}
@@ -39,7 +65,7 @@ func Ensure(projectRoot, worktreeDir, branch, name string) (string, error) {
 
 	if err := gitInDir(projectRoot, "worktree", "add", wtPath, branch); err != nil {
 		// Branch might not exist locally — try fetching
-		gitInDir(projectRoot, "fetch", "origin", branch)
+		gitInDir(projectRoot, fetchNew(branch)...)
 		if err := gitInDir(projectRoot, "worktree", "add", wtPath, branch); err != nil {
 			// Try creating/resetting branch from remote (-B forces if branch already exists)
 			if err := gitInDir(projectRoot, "worktree", "add", "-B", branch, wtPath, "origin/"+branch); err != nil {
@@ -51,6 +77,28 @@ func Ensure(projectRoot, worktreeDir, branch, name string) (string, error) {
 	return wtPath, nil
 }
 
+// Adoptable returns the path of the existing worktree name under worktreeDir
+// if it is a valid worktree with branch checked out, so a restarted worker
+// can continue in it.
+func Adoptable(projectRoot, worktreeDir, branch, name string) (string, bool) {
+	wtPath := filepath.Join(projectRoot, worktreeDir, name)
+	if !isValidWorktree(wtPath) || Branch(wtPath) != branch {
+		return "", false
+	}
+	return wtPath, true
+}
+
+// Unpushed reports whether the worktree has uncommitted changes or commits
+// that origin/<branch> (as last fetched) does not have.
+func Unpushed(wtPath, branch string) bool {
+	out, err := exec.Command("git", "-C", wtPath, "status", "--porcelain").Output()
+	if err == nil && len(bytes.TrimSpace(out)) > 0 {
+		return true
+	}
+	out, err = exec.Command("git", "-C", wtPath, "rev-list", "--count", "origin/"+branch+"..HEAD").Output()
+	return err == nil && strings.TrimSpace(string(out)) != "0"
+}
+
 // fixWorktreeRelPaths rewrites the .git pointer file in a worktree and the
 // corresponding gitdir file in the main repo to use relative paths. This is
 // necessary for Docker mode: the project root is bind-mounted into the
@@ -63,7 +111,9 @@ func fixWorktreeRelPaths(wtPath string) {
 	if err != nil {
 		return
 	}
-	content := strings.TrimSpace(string(data))
+	// Only strip the line ending: a path may legitimately start or end with
+	// spaces, and git writes it unquoted.
+	content := trimEOL(string(data))
 	if !strings.HasPrefix(content, "gitdir: ") {
 		return
 	}
@@ -89,7 +139,7 @@ func fixWorktreeRelPaths(wtPath string) {
 	if err != nil {
 		return
 	}
-	backPointer := strings.TrimSpace(string(data2))
+	backPointer := trimEOL(string(data2))
 	backPointer = filepath.FromSlash(backPointer)
 
 	if filepath.IsAbs(backPointer) {
@@ -102,23 +152,33 @@ func fixWorktreeRelPaths(wtPath string) {
 	}
 }
 
+// trimEOL removes trailing line endings.
+func trimEOL(s string) string {
+	return strings.TrimRight(s, "\r\n")
+}
+
+// ResolveBase returns baseBranch, or the repo's default branch if it is empty.
+func ResolveBase(ctx context.Context, repo, baseBranch string) string {
+	if baseBranch != "" {
+		return baseBranch
+	}
+	base, err := github.GetDefaultBranch(ctx, repo)
+	if err != nil {
+		return "main"
+	}
+	return base
+}
+
 // CreateForIssue creates a worktree for an issue, branching from the base branch.
 func CreateForIssue(ctx context.Context, projectRoot, worktreeDir, repo string, issueNum int, baseBranch string) (string, error) {
 	branch := fmt.Sprintf("auto/issue-%d", issueNum)
-
-	if baseBranch == "" {
-		var err error
-		baseBranch, err = github.GetDefaultBranch(ctx, repo)
-		if err != nil {
-			baseBranch = "main"
-		}
-	}
+	baseBranch = ResolveBase(ctx, repo, baseBranch)
 
 	// Prune stale worktree references before creating new ones
 	gitInDir(projectRoot, "worktree", "prune")
 
 	// Fetch latest base
-	gitInDir(projectRoot, "fetch", "origin", baseBranch)
+	gitInDir(projectRoot, fetchNew(baseBranch)...)
 
 	// Create branch from base (ignore error if already exists)
 	gitInDir(projectRoot, "branch", branch, "origin/"+baseBranch)
@@ -148,3 +208,266 @@ func gitInDir(dir string, args ...string) error {
 	}
 	return nil
 }
+
+// CommitAndPush stages paths, commits them with message and pushes the
+// worktree's HEAD to branch on origin. A non-empty trailer is appended to the
+// message as a git trailer.
+func CommitAndPush(wtPath, branch, message, trailer string, paths []string) error {
+	if err := gitInDir(wtPath, append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	if trailer != "" {
+		message += "\n\n" + trailer
+	}
+	if err := gitInDir(wtPath, "commit", "-m", message); err != nil {
+		return err
+	}
+	if err := gitInDir(wtPath, "push", "origin", "HEAD:"+branch); err != nil {
+		// Undo the local commit so a retry (by Claude) starts clean.
+		gitInDir(wtPath, "reset", "--hard", "HEAD~1")
+		return err
+	}
+	return nil
+}
+
+// Discard reverts uncommitted changes to paths in the worktree.
+func Discard(wtPath string, paths []string) {
+	gitInDir(wtPath, append([]string{"checkout", "HEAD", "--"}, paths...)...)
+}
+
+// Rebase moves the worktree branch's own commits from oldBase onto newBase
+// (git rebase --onto) and force-pushes the result with a lease. On conflict
+// the rebase is aborted and the branch is left unchanged.
+func Rebase(wtPath, branch, oldBase, newBase string) error {
+	if err := gitInDir(wtPath, "fetch", "origin", oldBase); err != nil {
+		return err
+	}
+	if newBase != oldBase {
+		if err := gitInDir(wtPath, fetchNew(newBase)...); err != nil {
+			return err
+		}
+	}
+	if err := gitInDir(wtPath, "rebase", "--onto", "origin/"+newBase, "origin/"+oldBase); err != nil {
+		gitInDir(wtPath, "rebase", "--abort")
+		return err
+	}
+	return gitInDir(wtPath, "push", "--force-with-lease", "origin", "HEAD:"+branch)
+}
+
+// Fetch updates origin/<branch> in the worktree.
+func Fetch(wtPath, branch string) error {
+	return gitInDir(wtPath, "fetch", "origin", branch)
+}
+
+// FetchedSince reports whether origin/<branch> has moved to commits pushed by
+// others since it pointed at rev, as opposed to only by the worktree's own
+// pushes. It reads the ref's reflog; when that cannot tell, it reports true.
+func FetchedSince(wtPath, branch, rev string) bool {
+	out, err := exec.Command("git", "-C", wtPath, "reflog", "show", "--format=%H %gs", "refs/remotes/origin/"+branch, "--").Output()
+	if err != nil {
+		return true
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		hash, subject, _ := strings.Cut(line, " ")
+		if hash == rev {
+			return false
+		}
+		if subject != "update by push" {
+			return true
+		}
+	}
+	return true
+}
+
+// MainRoot returns the top directory of the main working tree of the
+// repository dir is in, which differs from dir's own inside a linked
+// worktree. It falls back to dir.
+func MainRoot(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--git-common-dir").Output()
+	if err != nil {
+		return dir
+	}
+	common := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(dir, common)
+	}
+	if filepath.Base(common) != ".git" {
+		return dir // bare repository or a separate git dir
+	}
+	return filepath.Dir(common)
+}
+
+// IsAncestor reports whether commit a is an ancestor of (or the same as)
+// commit b. Unknown commits are never ancestors.
+func IsAncestor(wtPath, a, b string) bool {
+	return gitInDir(wtPath, "merge-base", "--is-ancestor", a, b) == nil
+}
+
+// FastForward moves the worktree to the last fetched origin/<branch>, which
+// must descend from HEAD.
+func FastForward(wtPath, branch string) error {
+	return gitInDir(wtPath, "merge", "--ff-only", "origin/"+branch)
+}
+
+// RebaseOnRemote replays the worktree's commits missing from the last
+// fetched origin/<branch> on top of it. On conflict the rebase is aborted and
+// the worktree is left unchanged.
+func RebaseOnRemote(wtPath, branch string) error {
+	if err := gitInDir(wtPath, "rebase", "origin/"+branch); err != nil {
+		gitInDir(wtPath, "rebase", "--abort")
+		return err
+	}
+	return nil
+}
+
+// ResetToRemote hard-resets the worktree to the last fetched origin/<branch>,
+// discarding local commits and uncommitted changes.
+func ResetToRemote(wtPath, branch string) error {
+	return gitInDir(wtPath, "reset", "--hard", "origin/"+branch)
+}
+
+// Branch returns the branch checked out in the worktree, or "" if it cannot
+// be determined (e.g. a broken worktree or detached HEAD).
+func Branch(wtPath string) string {
+	out, err := exec.Command("git", "-C", wtPath, "symbolic-ref", "--short", "-q", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Head returns the commit checked out in the worktree, or "" if it cannot be
+// determined.
+func Head(wtPath string) string {
+	out, err := exec.Command("git", "-C", wtPath, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// RevParse returns the commit rev resolves to in the worktree, or "".
+func RevParse(wtPath, rev string) string {
+	out, err := exec.Command("git", "-C", wtPath, "rev-parse", "--verify", "-q", rev+"^{commit}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// StartRebase rebases the worktree onto the last fetched origin/<base>. On
+// conflict the rebase is left in progress and the conflicted files are
+// returned along with the error; other failures abort it.
+func StartRebase(wtPath, base string) ([]string, error) {
+	err := gitInDir(wtPath, "rebase", "origin/"+base)
+	if err == nil {
+		return nil, nil
+	}
+	conflicts := Conflicts(wtPath)
+	if len(conflicts) == 0 {
+		AbortRebase(wtPath)
+	}
+	return conflicts, err
+}
+
+// Conflicts lists the files with unresolved merge conflicts.
+func Conflicts(wtPath string) []string {
+	out, err := exec.Command("git", "-C", wtPath, "diff", "--name-only", "--diff-filter=U").Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// RebaseInProgress reports whether a rebase is stopped in the worktree.
+func RebaseInProgress(wtPath string) bool {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		out, err := exec.Command("git", "-C", wtPath, "rev-parse", "--git-path", dir).Output()
+		if err != nil {
+			continue
+		}
+		p := strings.TrimSpace(string(out))
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(wtPath, p)
+		}
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AbortRebase aborts a stopped rebase, restoring the branch.
+func AbortRebase(wtPath string) {
+	gitInDir(wtPath, "rebase", "--abort")
+}
+
+// Status returns the output of git status for the worktree.
+func Status(wtPath string) string {
+	out, _ := exec.Command("git", "-C", wtPath, "status").Output()
+	return string(out)
+}
+
+// ForcePush pushes the worktree's HEAD to branch on origin, overwriting it
+// only if it still is what was last fetched (--force-with-lease).
+func ForcePush(wtPath, branch string) error {
+	return gitInDir(wtPath, "push", "--force-with-lease", "origin", "HEAD:"+branch)
+}
+
+// Push pushes the worktree's HEAD to branch on origin.
+func Push(wtPath, branch string) error {
+	return gitInDir(wtPath, "push", "origin", "HEAD:"+branch)
+}
+
+// CommitsSince returns the number of commits on the worktree's HEAD that rev
+// does not have (0 if it cannot be determined).
+func CommitsSince(wtPath, rev string) int {
+	out, err := exec.Command("git", "-C", wtPath, "rev-list", "--count", rev+"..HEAD").Output()
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	return n
+}
+
+// ChangedSince lists the tracked files that differ between commit rev and
+// the worktree, whether the change is committed or not.
+func ChangedSince(wtPath, rev string) ([]string, error) {
+	cmd := exec.Command("git", "-C", wtPath, "diff", "--name-only", "--no-renames", rev, "--")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff --name-only %s: %w (%s)", rev, err, stderr.String())
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// Restore puts paths back to their content at commit rev, deleting those
+// that did not exist there, then commits the result and pushes it to branch
+// on origin. Paths whose changes were never committed are only reset.
+func Restore(wtPath, branch, rev, message, trailer string, paths []string) error {
+	for _, p := range paths {
+		if gitInDir(wtPath, "cat-file", "-e", rev+":"+p) == nil {
+			if err := gitInDir(wtPath, "checkout", rev, "--", p); err != nil {
+				return err
+			}
+		} else if err := gitInDir(wtPath, "rm", "-q", "-f", "--ignore-unmatch", "--", p); err != nil {
+			return err
+		}
+	}
+	if gitInDir(wtPath, append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...) == nil {
+		return nil // nothing was committed
+	}
+	if trailer != "" {
+		message += "\n\n" + trailer
+	}
+	if err := gitInDir(wtPath, append([]string{"commit", "-m", message, "--"}, paths...)...); err != nil {
+		return err
+	}
+	if err := gitInDir(wtPath, "push", "origin", "HEAD:"+branch); err != nil {
+		gitInDir(wtPath, "reset", "--hard", "HEAD~1")
+		return err
+	}
+	return nil
+}
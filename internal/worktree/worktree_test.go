@@ -0,0 +1,94 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// readPointer returns the path a gitdir pointer file holds.
+func readPointer(t *testing.T, file string) string {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimPrefix(trimEOL(string(data)), "gitdir: ")
+}
+
+func TestEnsurePathWithSpaces(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME":     "auto-pr test",
+		"GIT_AUTHOR_EMAIL":    "test@example.com",
+		"GIT_COMMITTER_NAME":  "auto-pr test",
+		"GIT_COMMITTER_EMAIL": "test@example.com",
+		"GIT_CONFIG_GLOBAL":   "/dev/null",
+	} {
+		t.Setenv(k, v)
+	}
+	tmp, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(tmp, "my project")
+	git(t, tmp, "init", "-q", root)
+	git(t, root, "commit", "-q", "--allow-empty", "-m", "first")
+	git(t, root, "branch", "feature")
+
+	wtPath, err := Ensure(root, "work trees", "feature", "issue 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "work trees", "issue 1"); wtPath != want {
+		t.Fatalf("worktree at %q, want %q", wtPath, want)
+	}
+
+	// .git in the worktree points at its admin dir with a relative path.
+	gitdir := readPointer(t, filepath.Join(wtPath, ".git"))
+	if filepath.IsAbs(filepath.FromSlash(gitdir)) || strings.Contains(gitdir, `\`) {
+		t.Errorf(".git points at %q, want a relative, slash-separated path", gitdir)
+	}
+	adminDir := filepath.Join(wtPath, filepath.FromSlash(gitdir))
+	if filepath.Dir(adminDir) != filepath.Join(root, ".git", "worktrees") {
+		t.Errorf(".git resolves to %q, outside the repository's worktrees", adminDir)
+	}
+	if info, err := os.Stat(adminDir); err != nil || !info.IsDir() {
+		t.Fatalf(".git resolves to %q, which is not a directory", adminDir)
+	}
+
+	// The admin dir's gitdir points back at the worktree, also relatively.
+	back := readPointer(t, filepath.Join(adminDir, "gitdir"))
+	if filepath.IsAbs(filepath.FromSlash(back)) {
+		t.Errorf("gitdir points back at %q, want a relative path", back)
+	}
+	if got, want := filepath.Join(adminDir, filepath.FromSlash(back)), filepath.Join(wtPath, ".git"); got != want {
+		t.Errorf("gitdir resolves to %q, want %q", got, want)
+	}
+
+	if got := Branch(wtPath); got != "feature" {
+		t.Errorf("worktree on branch %q, want feature", got)
+	}
+	if !isValidWorktree(wtPath) {
+		t.Error("worktree not recognised as valid")
+	}
+
+	// Ensuring it again reuses the worktree.
+	again, err := Ensure(root, "work trees", "feature", "issue 1")
+	if err != nil || again != wtPath {
+		t.Errorf("second Ensure = %q, %v; want %q", again, err, wtPath)
+	}
+}
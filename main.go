@@ -3,8 +3,10 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"auto-pr/internal/cmd"
+	"auto-pr/internal/config"
 )
 
 func main() {
@@ -13,16 +15,59 @@ func main() {
 		os.Exit(1)
 	}
 
-	subcmd := os.Args[1]
-	args := os.Args[2:]
+	args := os.Args[1:]
+	configPath := os.Getenv("AUTOPR_CONFIG")
+	switch {
+	case args[0] == "--config":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Error: --config needs a path")
+			os.Exit(1)
+		}
+		configPath, args = args[1], args[2:]
+	case strings.HasPrefix(args[0], "--config="):
+		configPath, args = strings.TrimPrefix(args[0], "--config="), args[1:]
+	}
+	if configPath != "" {
+		if err := config.SetPath(configPath); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	}
+	if len(args) == 0 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	subcmd := args[0]
+	args = args[1:]
 
 	switch subcmd {
 	case "reviews":
 		os.Exit(cmd.RunReviews(args))
 	case "reply":
 		os.Exit(cmd.RunReply(args))
+	case "review":
+		os.Exit(cmd.RunReview(args))
 	case "watch":
 		os.Exit(cmd.RunWatch(args))
+	case "cost":
+		os.Exit(cmd.RunCost(args))
+	case "report":
+		os.Exit(cmd.RunReport(args))
+	case "tree":
+		os.Exit(cmd.RunTree(args))
+	case "worktree":
+		os.Exit(cmd.RunWorktree(args))
+	case "clean":
+		os.Exit(cmd.RunClean(args))
+	case "doctor":
+		os.Exit(cmd.RunDoctor(args))
+	case "image":
+		os.Exit(cmd.RunImage(args))
+	case "config":
+		os.Exit(cmd.RunConfig(args))
+	case "version", "--version":
+		os.Exit(cmd.RunVersion(args))
 	case "--help", "-h", "help":
 		printUsage()
 		os.Exit(0)
@@ -34,12 +79,25 @@ func main() {
 }
 
 func printUsage() {
-	fmt.Println("Usage: auto-pr <command> [options]")
+	fmt.Println("Usage: auto-pr [--config PATH] <command> [options]")
+	fmt.Println()
+	fmt.Println("  --config PATH  Read this config file instead of <repo>/.pr-watch.conf")
+	fmt.Println("                 (also AUTOPR_CONFIG); it must exist")
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  reviews    Read PR review comments")
 	fmt.Println("  reply      Reply to PR review comments")
+	fmt.Println("  review     Approve, request changes on, or comment on a PR")
 	fmt.Println("  watch      Auto-watch PR/repo for new reviews and issues")
+	fmt.Println("  cost       Show Claude token usage and cost per issue")
+	fmt.Println("  report     Summarize issue and PR activity with live PR states")
+	fmt.Println("  tree       Show issues, worktrees and PRs as a tree")
+	fmt.Println("  worktree   List worktrees or prune orphaned ones")
+	fmt.Println("  clean      Remove stale worktrees and old issue state")
+	fmt.Println("  doctor     Check gh, claude, Docker and Claude auth before watching")
+	fmt.Println("  image      Build or remove the Docker worker image")
+	fmt.Println("  config     Check .pr-watch.conf or show the effective config and its sources")
+	fmt.Println("  version    Show auto-pr and gh versions")
 	fmt.Println()
 	fmt.Println("Run 'auto-pr <command> --help' for details on each command.")
 }